@@ -0,0 +1,358 @@
+// Package boltstore implements tus.Store and tus.UploadStore on top of bbolt,
+// a pure Go embedded database kept in a single file.
+//
+// A database may hold several buckets, so the uploads of different tus endpoints
+// can be kept apart in the same file.
+package boltstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eventials/go-tus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	ErrClosed      = errors.New("store is closed.")
+	ErrEmptyBucket = errors.New("bucket name can't be empty.")
+)
+
+// OpenTimeout is how long Open waits for other process holding the database file.
+var OpenTimeout = time.Second
+
+// DB is a bbolt database shared by the stores of each bucket.
+// The file is closed once the DB and all its stores are closed.
+type DB struct {
+	db *bolt.DB
+
+	mu     sync.Mutex
+	refs   int
+	closed bool
+}
+
+// Open opens the database at path, creating it if needed.
+func Open(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: OpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{db: db, refs: 1}, nil
+}
+
+// Store returns a tus.Store saving the urls in the bucket, creating it if needed.
+func (d *DB) Store(bucket string) (tus.Store, error) {
+	r, err := d.acquire(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltStore{ref: r}, nil
+}
+
+// UploadStore returns a tus.UploadStore saving the records in the bucket, creating it if needed.
+func (d *DB) UploadStore(bucket string) (tus.UploadStore, error) {
+	r, err := d.acquire(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltUploadStore{ref: r}, nil
+}
+
+// Buckets returns the names of the buckets in the database.
+func (d *DB) Buckets() ([]string, error) {
+	var buckets []string
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			buckets = append(buckets, string(name))
+			return nil
+		})
+	})
+
+	return buckets, err
+}
+
+// DeleteBucket removes the bucket and all its entries.
+func (d *DB) DeleteBucket(bucket string) error {
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket([]byte(bucket))
+	})
+
+	if err == bolt.ErrBucketNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// Close releases the DB. The file is closed when all stores are closed too.
+func (d *DB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+
+	d.closed = true
+
+	return d.release()
+}
+
+func (d *DB) acquire(bucket string) (*ref, error) {
+	if len(bucket) == 0 {
+		return nil, ErrEmptyBucket
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.refs == 0 || d.closed {
+		return nil, ErrClosed
+	}
+
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.refs++
+
+	return &ref{db: d, bucket: []byte(bucket)}, nil
+}
+
+// release must be called holding mu.
+func (d *DB) release() error {
+	d.refs--
+
+	if d.refs == 0 {
+		return d.db.Close()
+	}
+
+	return nil
+}
+
+// ref is a store reference to a DB bucket.
+type ref struct {
+	db     *DB
+	bucket []byte
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func (r *ref) view(fn func(b *bolt.Bucket) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	return r.db.db.View(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket(r.bucket))
+	})
+}
+
+func (r *ref) update(fn func(b *bolt.Bucket) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	return r.db.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(r.bucket)
+		if err != nil {
+			return err
+		}
+
+		return fn(b)
+	})
+}
+
+func (r *ref) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	r.closed = true
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.release()
+}
+
+// get returns a copy of the value, bbolt values are only valid inside the transaction.
+func (r *ref) get(key string) ([]byte, error) {
+	var value []byte
+
+	err := r.view(func(b *bolt.Bucket) error {
+		if b == nil {
+			return nil
+		}
+
+		if v := b.Get([]byte(key)); v != nil {
+			value = append([]byte{}, v...)
+		}
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (r *ref) put(key string, value []byte) error {
+	return r.update(func(b *bolt.Bucket) error {
+		return b.Put([]byte(key), value)
+	})
+}
+
+func (r *ref) delete(key string) error {
+	return r.update(func(b *bolt.Bucket) error {
+		return b.Delete([]byte(key))
+	})
+}
+
+func (r *ref) forEach(fn func(k, v []byte) error) error {
+	return r.view(func(b *bolt.Bucket) error {
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(fn)
+	})
+}
+
+// BoltStore implements tus.Store.
+type BoltStore struct {
+	ref *ref
+}
+
+// NewBoltStore opens the database at path and returns the store of the bucket.
+// Closing the store closes the database.
+func NewBoltStore(path, bucket string) (tus.Store, error) {
+	db, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return db.Store(bucket)
+}
+
+func (s *BoltStore) Get(fingerprint string) (string, bool) {
+	url, err := s.ref.get(fingerprint)
+	return string(url), err == nil && url != nil
+}
+
+func (s *BoltStore) Set(fingerprint, url string) {
+	s.ref.put(fingerprint, []byte(url))
+}
+
+func (s *BoltStore) Delete(fingerprint string) {
+	s.ref.delete(fingerprint)
+}
+
+func (s *BoltStore) Close() {
+	s.ref.close()
+}
+
+// Keys returns the fingerprints in the store.
+func (s *BoltStore) Keys() []string {
+	var keys []string
+
+	s.ref.forEach(func(k, _ []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+
+	return keys
+}
+
+// BoltUploadStore implements tus.UploadStore, saving the records JSON encoded.
+type BoltUploadStore struct {
+	ref *ref
+}
+
+// NewBoltUploadStore opens the database at path and returns the upload store of the bucket.
+// Closing the store closes the database.
+func NewBoltUploadStore(path, bucket string) (tus.UploadStore, error) {
+	db, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return db.UploadStore(bucket)
+}
+
+func (s *BoltUploadStore) Get(ctx context.Context, fingerprint string) (*tus.UploadRecord, error) {
+	value, err := s.ref.get(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, tus.ErrUploadNotFound
+	}
+
+	var record tus.UploadRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (s *BoltUploadStore) Set(ctx context.Context, record *tus.UploadRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.ref.put(record.Fingerprint, value)
+}
+
+func (s *BoltUploadStore) Delete(ctx context.Context, fingerprint string) error {
+	return s.ref.delete(fingerprint)
+}
+
+func (s *BoltUploadStore) List(ctx context.Context) ([]*tus.UploadRecord, error) {
+	var records []*tus.UploadRecord
+
+	err := s.ref.forEach(func(_, v []byte) error {
+		var record tus.UploadRecord
+		if err := json.Unmarshal(v, &record); err != nil {
+			return err
+		}
+
+		records = append(records, &record)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (s *BoltUploadStore) Close() error {
+	return s.ref.close()
+}
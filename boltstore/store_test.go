@@ -0,0 +1,241 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eventials/go-tus"
+	"github.com/eventials/go-tus/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	assert.Nil(t, err)
+
+	return filepath.Join(dir, "tus.db"), func() { os.RemoveAll(dir) }
+}
+
+func TestBoltStore(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	s, err := NewBoltStore(path, "uploads")
+	assert.Nil(t, err)
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("a", "http://tus.io/uploads/1")
+	s.Set("a", "http://tus.io/uploads/2")
+
+	url, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/2", url)
+	assert.Equal(t, []string{"a"}, s.(*BoltStore).Keys())
+
+	s.Delete("a")
+
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("b", "http://tus.io/uploads/3")
+	s.Close()
+
+	// The database file is closed, so it can be opened again.
+	s, err = NewBoltStore(path, "uploads")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	url, ok = s.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/3", url)
+}
+
+func TestBoltUploadStore(t *testing.T) {
+	ctx := context.Background()
+
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	s, err := NewBoltUploadStore(path, "uploads")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+
+	record := &tus.UploadRecord{
+		Fingerprint: "a",
+		Url:         "http://tus.io/uploads/1",
+		Size:        10,
+		Offset:      4,
+		Metadata:    tus.Metadata{"filename": "file.txt"},
+		PartialUrls: []string{"http://tus.io/uploads/2"},
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	assert.Nil(t, s.Set(ctx, record))
+	assert.Nil(t, s.Set(ctx, &tus.UploadRecord{Fingerprint: "b", Url: "http://tus.io/uploads/3"}))
+
+	stored, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, record, stored)
+
+	records, err := s.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+
+	assert.Nil(t, s.Delete(ctx, "a"))
+	assert.Nil(t, s.Delete(ctx, "a"))
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+}
+
+func TestBuckets(t *testing.T) {
+	ctx := context.Background()
+
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	db, err := Open(path)
+	assert.Nil(t, err)
+
+	first, err := db.UploadStore("https://first.example.com/files/")
+	assert.Nil(t, err)
+
+	second, err := db.UploadStore("https://second.example.com/files/")
+	assert.Nil(t, err)
+
+	_, err = db.Store("")
+	assert.Equal(t, ErrEmptyBucket, err)
+
+	assert.Nil(t, first.Set(ctx, &tus.UploadRecord{Fingerprint: "a", Url: "https://first.example.com/files/1"}))
+	assert.Nil(t, second.Set(ctx, &tus.UploadRecord{Fingerprint: "a", Url: "https://second.example.com/files/1"}))
+
+	record, err := first.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://first.example.com/files/1", record.Url)
+
+	record, err = second.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://second.example.com/files/1", record.Url)
+
+	buckets, err := db.Buckets()
+	assert.Nil(t, err)
+	sort.Strings(buckets)
+	assert.Equal(t, []string{"https://first.example.com/files/", "https://second.example.com/files/"}, buckets)
+
+	assert.Nil(t, db.DeleteBucket("https://second.example.com/files/"))
+
+	_, err = second.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+
+	assert.Nil(t, first.Close())
+	assert.Nil(t, second.Close())
+	assert.Nil(t, db.Close())
+}
+
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	db, err := Open(path)
+	assert.Nil(t, err)
+
+	s, err := db.UploadStore("uploads")
+	assert.Nil(t, err)
+
+	// The store keeps the database open.
+	assert.Nil(t, db.Close())
+	assert.Equal(t, ErrClosed, db.Close())
+
+	_, err = db.Store("other")
+	assert.Equal(t, ErrClosed, err)
+
+	assert.Nil(t, s.Set(ctx, &tus.UploadRecord{Fingerprint: "a"}))
+
+	assert.Nil(t, s.Close())
+	assert.Equal(t, ErrClosed, s.Close())
+
+	assert.Equal(t, ErrClosed, s.Set(ctx, &tus.UploadRecord{Fingerprint: "a"}))
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, ErrClosed, err)
+
+	// The file was released.
+	s, err = NewBoltUploadStore(path, "uploads")
+	assert.Nil(t, err)
+
+	_, err = s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Nil(t, s.Close())
+}
+
+func TestConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	s, err := NewBoltUploadStore(path, "uploads")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				fingerprint := string(rune('a'+i)) + string(rune('a'+j))
+
+				assert.Nil(t, s.Set(ctx, &tus.UploadRecord{Fingerprint: fingerprint}))
+
+				_, err := s.Get(ctx, fingerprint)
+				assert.Nil(t, err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	records, err := s.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, records, 160)
+}
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var n int
+
+	storetest.TestStore(t, func(t *testing.T) tus.Store {
+		n++
+		s, err := NewBoltStore(filepath.Join(dir, fmt.Sprintf("%d.db", n)), "uploads")
+		assert.Nil(t, err)
+		return s
+	})
+
+	storetest.TestUploadStore(t, func(t *testing.T) tus.UploadStore {
+		n++
+		s, err := NewBoltUploadStore(filepath.Join(dir, fmt.Sprintf("%d.db", n)), "uploads")
+		assert.Nil(t, err)
+		return s
+	})
+}
@@ -0,0 +1,151 @@
+package tus
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Capabilities describes the protocol versions and extensions supported by the server,
+// as advertised in the response of an OPTIONS request.
+type Capabilities struct {
+	// Versions lists the supported protocol versions, preferred first.
+	Versions []string
+	// Extensions lists the supported protocol extensions.
+	Extensions []string
+	// MaxSize is the maximum allowed size of an upload in bytes, zero if unlimited.
+	MaxSize int64
+	// ChecksumAlgorithms lists the algorithms supported by the Checksum extension.
+	ChecksumAlgorithms []string
+	// Unknown is true if the server didn't advertise its capabilities,
+	// in which case the lists above are empty.
+	Unknown bool
+}
+
+// SupportsVersion returns whether the server supports the protocol version.
+// A server which doesn't advertise its versions is assumed to support it.
+func (c *Capabilities) SupportsVersion(version string) bool {
+	return len(c.Versions) == 0 || contains(c.Versions, version)
+}
+
+// SupportsExtension returns whether the server supports the protocol extension.
+func (c *Capabilities) SupportsExtension(extension string) bool {
+	return contains(c.Extensions, extension)
+}
+
+// SupportsChecksumAlgorithm returns whether the server supports the checksum algorithm.
+func (c *Capabilities) SupportsChecksumAlgorithm(algorithm ChecksumAlgorithm) bool {
+	return c.SupportsExtension("checksum") && contains(c.ChecksumAlgorithms, string(algorithm))
+}
+
+// Capabilities discovers the capabilities of the server.
+// The result is cached on the Client, only the first successful call issues a request.
+// A server which doesn't answer the OPTIONS request as a tus server, e.g. with 404 or 405,
+// has unknown capabilities and the client doesn't check the uploads against them.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	c.mu.Lock()
+	capabilities := c.capabilities
+	c.mu.Unlock()
+
+	if capabilities != nil {
+		return capabilities, nil
+	}
+
+	// The request is made without holding the lock, so other requests don't wait for it.
+	capabilities, cache, err := c.fetchCapabilities(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !cache {
+		return capabilities, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capabilities == nil {
+		c.capabilities = capabilities
+	}
+
+	return c.capabilities, nil
+}
+
+// fetchCapabilities issues the OPTIONS request, returning whether the result can be cached.
+// Server errors aren't cached since they may be temporary.
+func (c *Client) fetchCapabilities(ctx context.Context) (*Capabilities, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "OPTIONS", c.Url, nil)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	res, err := c.Do(req)
+
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		return &Capabilities{Unknown: true}, false, nil
+	}
+
+	if res.StatusCode != 200 && res.StatusCode != 204 {
+		return &Capabilities{Unknown: true}, true, nil
+	}
+
+	if res.Header.Get("Tus-Resumable") == "" && res.Header.Get("Tus-Version") == "" {
+		return &Capabilities{Unknown: true}, true, nil
+	}
+
+	capabilities := &Capabilities{
+		Versions:           splitHeader(res.Header.Get("Tus-Version")),
+		Extensions:         splitHeader(res.Header.Get("Tus-Extension")),
+		ChecksumAlgorithms: splitHeader(res.Header.Get("Tus-Checksum-Algorithm")),
+	}
+
+	if maxSize := res.Header.Get("Tus-Max-Size"); len(maxSize) > 0 {
+		if capabilities.MaxSize, err = strconv.ParseInt(maxSize, 10, 64); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return capabilities, true, nil
+}
+
+// requireExtension returns err if the server is known not to support the extension.
+func (c *Client) requireExtension(ctx context.Context, extension string, err error) error {
+	capabilities, cerr := c.Capabilities(ctx)
+
+	if cerr != nil {
+		return cerr
+	}
+
+	if !capabilities.Unknown && !capabilities.SupportsExtension(extension) {
+		return err
+	}
+
+	return nil
+}
+
+// checkChecksumSupport returns ErrChecksumNotSupported if the configured checksum
+// algorithm is known not to be supported by the server.
+func (c *Client) checkChecksumSupport(ctx context.Context) error {
+	if c.Config.ChecksumAlgorithm == "" {
+		return nil
+	}
+
+	capabilities, err := c.Capabilities(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if !capabilities.Unknown && !capabilities.SupportsChecksumAlgorithm(c.Config.ChecksumAlgorithm) {
+		return ErrChecksumNotSupported
+	}
+
+	return nil
+}
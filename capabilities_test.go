@@ -0,0 +1,152 @@
+package tus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tus/tusd/pkg/filestore"
+)
+
+// capabilitiesHandler answers OPTIONS requests with the given headers and counts all requests by method.
+func capabilitiesHandler(header http.Header, requests map[string]*int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n, ok := requests[r.Method]; ok {
+			atomic.AddInt32(n, 1)
+		}
+
+		w.Header().Set("Tus-Resumable", ProtocolVersion)
+
+		if r.Method != "OPTIONS" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestCapabilities(t *testing.T) {
+	var options int32
+
+	ts := httptest.NewServer(capabilitiesHandler(http.Header{
+		"Tus-Version":            []string{"1.0.0,0.2.2"},
+		"Tus-Extension":          []string{"creation,termination, checksum"},
+		"Tus-Max-Size":           []string{"1073741824"},
+		"Tus-Checksum-Algorithm": []string{"md5,sha1"},
+	}, map[string]*int32{"OPTIONS": &options}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	assert.Nil(t, err)
+
+	capabilities, err := client.Capabilities(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1.0.0", "0.2.2"}, capabilities.Versions)
+	assert.Equal(t, []string{"creation", "termination", "checksum"}, capabilities.Extensions)
+	assert.EqualValues(t, 1073741824, capabilities.MaxSize)
+	assert.Equal(t, []string{"md5", "sha1"}, capabilities.ChecksumAlgorithms)
+
+	assert.True(t, capabilities.SupportsVersion(ProtocolVersion))
+	assert.True(t, capabilities.SupportsExtension("termination"))
+	assert.False(t, capabilities.SupportsExtension("concatenation"))
+	assert.True(t, capabilities.SupportsChecksumAlgorithm(ChecksumSHA1))
+	assert.False(t, capabilities.SupportsChecksumAlgorithm(ChecksumSHA256))
+
+	cached, err := client.Capabilities(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, capabilities, cached)
+	assert.EqualValues(t, 1, options)
+}
+
+func TestCapabilitiesFailFast(t *testing.T) {
+	var posts, deletes int32
+
+	ts := httptest.NewServer(capabilitiesHandler(http.Header{
+		"Tus-Version":   []string{"1.0.0"},
+		"Tus-Extension": []string{"creation"},
+		"Tus-Max-Size":  []string{"5"},
+	}, map[string]*int32{"POST": &posts, "DELETE": &deletes}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	assert.Nil(t, err)
+
+	_, err = client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Equal(t, ErrLargeUpload, err)
+
+	_, err = client.CreateParallelUpload(NewUploadFromBytes([]byte("12345")), 2)
+	assert.Equal(t, ErrConcatenationNotSupported, err)
+
+	err = client.TerminateUpload(ts.URL + "/123")
+	assert.Equal(t, ErrTerminationNotSupported, err)
+
+	assert.EqualValues(t, 0, posts)
+	assert.EqualValues(t, 0, deletes)
+}
+
+func TestCapabilitiesVersionMismatch(t *testing.T) {
+	ts := httptest.NewServer(capabilitiesHandler(http.Header{
+		"Tus-Version":   []string{"2.0.0"},
+		"Tus-Extension": []string{"creation"},
+	}, nil))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	assert.Nil(t, err)
+
+	_, err = client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Equal(t, ErrVersionMismatch, err)
+}
+
+func TestCapabilitiesUnknown(t *testing.T) {
+	var options, patches int32
+
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	tusd := newTusdHandler(store)
+
+	// A proxy which doesn't forward OPTIONS requests to the tus server.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "OPTIONS":
+			atomic.AddInt32(&options, 1)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		case "PATCH":
+			atomic.AddInt32(&patches, 1)
+		}
+
+		tusd.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), nil)
+	assert.Nil(t, err)
+
+	capabilities, err := client.Capabilities(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, capabilities.Unknown)
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, uploader.Offset())
+
+	assert.Nil(t, uploader.Upload())
+	assert.EqualValues(t, 10, uploader.Offset())
+	assert.EqualValues(t, 1, patches)
+
+	assert.Nil(t, client.TerminateUpload(uploader.Url()))
+	assert.EqualValues(t, 1, options)
+}
@@ -0,0 +1,49 @@
+package tus
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+)
+
+// ChecksumAlgorithm is a hash algorithm used by the Checksum extension.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumSHA1   ChecksumAlgorithm = "sha1"
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumCRC32  ChecksumAlgorithm = "crc32"
+)
+
+// Valid returns whether the algorithm is supported by the client.
+func (a ChecksumAlgorithm) Valid() bool {
+	return a.newHash() != nil
+}
+
+func (a ChecksumAlgorithm) newHash() hash.Hash {
+	switch a {
+	case ChecksumSHA1:
+		return sha1.New()
+	case ChecksumMD5:
+		return md5.New()
+	case ChecksumSHA256:
+		return sha256.New()
+	case ChecksumCRC32:
+		return crc32.NewIEEE()
+	default:
+		return nil
+	}
+}
+
+// checksum returns the Upload-Checksum header value for data.
+func (a ChecksumAlgorithm) checksum(data []byte) string {
+	h := a.newHash()
+	h.Write(data)
+
+	return fmt.Sprintf("%s %s", a, base64.StdEncoding.EncodeToString(h.Sum(nil)))
+}
@@ -0,0 +1,142 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tus/tusd/pkg/filestore"
+)
+
+// checksumHandler adds the Checksum extension to a tusd handler.
+// The first corruptions PATCH requests are rejected as if the chunk was corrupted.
+func checksumHandler(h http.Handler, corruptions int32, patches *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "OPTIONS":
+			w.Header().Set("Tus-Resumable", ProtocolVersion)
+			w.Header().Set("Tus-Version", ProtocolVersion)
+			w.Header().Set("Tus-Extension", "creation,termination,checksum")
+			w.Header().Set("Tus-Checksum-Algorithm", "sha1,md5,sha256,crc32")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case "PATCH":
+			atomic.AddInt32(patches, 1)
+
+			body, _ := ioutil.ReadAll(r.Body)
+			parts := strings.SplitN(r.Header.Get("Upload-Checksum"), " ", 2)
+
+			if len(parts) != 2 || ChecksumAlgorithm(parts[0]).checksum(body) != r.Header.Get("Upload-Checksum") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if atomic.AddInt32(&corruptions, -1) >= 0 {
+				w.WriteHeader(460)
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestChecksum(t *testing.T) {
+	data := []byte("hello")
+
+	assert.Equal(t, "sha1 qvTGHdzF6KLavt4PO0gs2a6pQ00=", ChecksumSHA1.checksum(data))
+	assert.Equal(t, "md5 XUFAKrxLKna5cZ2REBfFkg==", ChecksumMD5.checksum(data))
+	assert.Equal(t, "sha256 LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=", ChecksumSHA256.checksum(data))
+	assert.Equal(t, "crc32 NhCmhg==", ChecksumCRC32.checksum(data))
+
+	assert.False(t, ChecksumAlgorithm("sha3").Valid())
+}
+
+func TestChecksumUpload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var patches int32
+
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	ts := httptest.NewServer(checksumHandler(newTusdHandler(store), 1, &patches))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChecksumAlgorithm = ChecksumSHA256
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, patches)
+
+	up, err := store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	assert.Nil(t, err)
+
+	fi, err := up.GetInfo(ctx)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, fi.Offset)
+}
+
+func TestChecksumUploadMismatch(t *testing.T) {
+	var patches int32
+
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	ts := httptest.NewServer(checksumHandler(newTusdHandler(store), maxChecksumRetries+1, &patches))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChecksumAlgorithm = ChecksumCRC32
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Equal(t, ErrChecksumMismatch, err)
+	assert.EqualValues(t, maxChecksumRetries+1, patches)
+}
+
+func TestChecksumNotSupported(t *testing.T) {
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	ts := httptest.NewServer(newTusdHandler(store))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChecksumAlgorithm = ChecksumSHA1
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Equal(t, ErrChecksumNotSupported, err)
+	assert.Nil(t, uploader)
+}
@@ -1,12 +1,16 @@
 package tus
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	netUrl "net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -22,6 +26,11 @@ type Client struct {
 	Header  http.Header
 
 	client *http.Client
+
+	mu           sync.Mutex
+	capabilities *Capabilities
+
+	storeMu sync.Mutex
 }
 
 // NewClient creates a new tus client.
@@ -59,34 +68,148 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 
 	req.Header.Set("Tus-Resumable", ProtocolVersion)
 
-	return c.client.Do(req)
+	res, err := c.client.Do(req)
+
+	// Report the cancellation as is instead of a wrapped transport error.
+	if err != nil && req.Context().Err() != nil {
+		return nil, req.Context().Err()
+	}
+
+	return res, err
 }
 
 // CreateUpload creates a new upload in the server.
 func (c *Client) CreateUpload(u *Upload) (*Uploader, error) {
+	return c.CreateUploadWithContext(context.Background(), u)
+}
+
+// CreateUploadWithContext creates a new upload in the server using the context for all requests.
+func (c *Client) CreateUploadWithContext(ctx context.Context, u *Upload) (*Uploader, error) {
 	if u == nil {
 		return nil, ErrNilUpload
 	}
 
+	if err := c.fingerprint(u); err != nil {
+		return nil, err
+	}
+
 	if c.Config.Resume && len(u.Fingerprint) == 0 {
 		return nil, ErrFingerprintNotSet
 	}
 
-	req, err := http.NewRequest("POST", c.Url, nil)
+	if err := c.checkUploadSupport(ctx, u); err != nil {
+		return nil, err
+	}
+
+	capabilities, err := c.Capabilities(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Length", "0")
-	req.Header.Set("Upload-Length", strconv.FormatInt(u.size, 10))
-	req.Header.Set("Upload-Metadata", u.EncodedMetadata())
+	header := make(http.Header)
+	header.Set("Upload-Metadata", u.EncodedMetadata())
 
-	res, err := c.Do(req)
+	var data []byte
+
+	if u.sizeIsDeferred {
+		header.Set("Upload-Defer-Length", "1")
+	} else {
+		header.Set("Upload-Length", strconv.FormatInt(u.size, 10))
+
+		// Send the first chunck along with the creation request, saving a round trip.
+		if capabilities.SupportsExtension("creation-with-upload") && u.size > 0 {
+			if data, err = u.readChunck(0, c.Config.ChunkSize); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	url, offset, expires, err := c.createUpload(ctx, header, data)
 
 	if err != nil {
 		return nil, err
 	}
+
+	u.updateProgress(offset)
+
+	uploader := NewUploader(c, url, u, offset)
+	uploader.expires = expires
+	uploader.stats.sent = int64(len(data))
+
+	if c.Config.Resume {
+		uploader.record = &UploadRecord{
+			Fingerprint: u.Fingerprint,
+			Url:         url,
+			Metadata:    u.Metadata,
+			CreatedAt:   time.Now(),
+		}
+
+		if err := uploader.saveRecord(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return uploader, nil
+}
+
+// checkUploadSupport fails fast when the server can't accept the upload.
+func (c *Client) checkUploadSupport(ctx context.Context, u *Upload) error {
+	capabilities, err := c.Capabilities(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if capabilities.Unknown {
+		return nil
+	}
+
+	if !capabilities.SupportsVersion(ProtocolVersion) {
+		return ErrVersionMismatch
+	}
+
+	if u.sizeIsDeferred && !capabilities.SupportsExtension("creation-defer-length") {
+		return ErrDeferLengthNotSupported
+	}
+
+	if capabilities.MaxSize > 0 && u.size > capabilities.MaxSize {
+		return ErrLargeUpload
+	}
+
+	return c.checkChecksumSupport(ctx)
+}
+
+// createUpload creates an upload resource in the server with the given headers and returns its url,
+// offset and expiration. Any data is sent as the first chunck of the upload, using the Creation With Upload extension.
+func (c *Client) createUpload(ctx context.Context, header http.Header, data []byte) (string, int64, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.Url, bytes.NewReader(data))
+
+	if err != nil {
+		return "", -1, time.Time{}, err
+	}
+
+	for k, v := range header {
+		if len(v) > 0 && len(v[0]) > 0 {
+			req.Header[k] = v
+		}
+	}
+
+	req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+
+	if len(data) > 0 {
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+
+		if c.Config.ChecksumAlgorithm != "" {
+			req.Header.Set("Upload-Checksum", c.Config.ChecksumAlgorithm.checksum(data))
+		}
+	}
+
+	res, err := c.Do(req)
+
+	if err != nil {
+		return "", -1, time.Time{}, err
+	}
 	defer res.Body.Close()
 
 	switch res.StatusCode {
@@ -95,20 +218,26 @@ func (c *Client) CreateUpload(u *Upload) (*Uploader, error) {
 
 		newURL, err := c.resolveLocationURL(location)
 		if err != nil {
-			return nil, err
+			return "", -1, time.Time{}, err
 		}
 
-		if c.Config.Resume {
-			c.Config.Store.Set(u.Fingerprint, newURL.String())
+		var offset int64
+
+		if value := res.Header.Get("Upload-Offset"); len(value) > 0 {
+			if offset, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return "", -1, time.Time{}, err
+			}
 		}
 
-		return NewUploader(c, newURL.String(), u, 0), nil
+		return newURL.String(), offset, parseExpires(res), nil
 	case 412:
-		return nil, ErrVersionMismatch
+		return "", -1, time.Time{}, ErrVersionMismatch
 	case 413:
-		return nil, ErrLargeUpload
+		return "", -1, time.Time{}, ErrLargeUpload
+	case 460:
+		return "", -1, time.Time{}, ErrChecksumMismatch
 	default:
-		return nil, newClientError(res)
+		return "", -1, time.Time{}, newClientError(res)
 	}
 }
 
@@ -128,49 +257,148 @@ func (c *Client) resolveLocationURL(location string) (*netUrl.URL, error) {
 
 // ResumeUpload resumes the upload if already created, otherwise it will return an error.
 func (c *Client) ResumeUpload(u *Upload) (*Uploader, error) {
+	return c.ResumeUploadWithContext(context.Background(), u)
+}
+
+// ResumeUploadWithContext resumes the upload if already created using the context for all requests,
+// otherwise it will return an error.
+func (c *Client) ResumeUploadWithContext(ctx context.Context, u *Upload) (*Uploader, error) {
 	if u == nil {
 		return nil, ErrNilUpload
 	}
 
 	if !c.Config.Resume {
 		return nil, ErrResumeNotEnabled
-	} else if len(u.Fingerprint) == 0 {
+	}
+
+	if err := c.fingerprint(u); err != nil {
+		return nil, err
+	}
+
+	if len(u.Fingerprint) == 0 {
 		return nil, ErrFingerprintNotSet
 	}
 
-	url, found := c.Config.Store.Get(u.Fingerprint)
+	return c.resumeStoredUpload(ctx, u)
+}
+
+// resumeStoredUpload creates an Uploader for the upload stored in the Store.
+// Uploads already expired or which doesn't exist anymore are removed from the Store.
+func (c *Client) resumeStoredUpload(ctx context.Context, u *Upload) (*Uploader, error) {
+	record, err := c.loadRecord(ctx, u.Fingerprint)
 
-	if !found {
+	if err != nil {
+		return nil, err
+	}
+
+	// Parallel uploads have no url until their partial uploads are concatenated.
+	if len(record.Url) == 0 {
 		return nil, ErrUploadNotFound
 	}
 
-	offset, err := c.getUploadOffset(url)
+	offset, expires, err := c.getUploadOffset(ctx, record.Url)
+
+	if err == ErrUploadNotFound {
+		if err := c.deleteRecord(ctx, u.Fingerprint); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrUploadNotFound
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	return NewUploader(c, url, u, offset), nil
+	uploader := NewUploader(c, record.Url, u, offset)
+	uploader.record = record
+	uploader.expires = record.ExpiresAt
+	uploader.updateExpires(expires)
+
+	if err := uploader.saveRecord(ctx); err != nil {
+		return nil, err
+	}
+
+	return uploader, nil
 }
 
 // CreateOrResumeUpload resumes the upload if already created or creates a new upload in the server.
 func (c *Client) CreateOrResumeUpload(u *Upload) (*Uploader, error) {
+	return c.CreateOrResumeUploadWithContext(context.Background(), u)
+}
+
+// CreateOrResumeUploadWithContext resumes the upload if already created or creates a new upload
+// in the server, using the context for all requests.
+func (c *Client) CreateOrResumeUploadWithContext(ctx context.Context, u *Upload) (*Uploader, error) {
 	if u == nil {
 		return nil, ErrNilUpload
 	}
 
-	uploader, err := c.ResumeUpload(u)
+	uploader, err := c.ResumeUploadWithContext(ctx, u)
 
 	if err == nil {
 		return uploader, err
 	} else if (err == ErrResumeNotEnabled) || (err == ErrUploadNotFound) {
-		return c.CreateUpload(u)
+		return c.CreateUploadWithContext(ctx, u)
 	}
 
 	return nil, err
 }
 
-func (c *Client) uploadChunck(url string, body io.Reader, size int64, offset int64) (int64, error) {
+// TerminateUpload terminates the upload on the server, freeing its resources.
+// Any further request to the upload url will fail.
+func (c *Client) TerminateUpload(url string) error {
+	return c.TerminateUploadWithContext(context.Background(), url)
+}
+
+// TerminateUploadWithContext terminates the upload on the server using the context for the requests.
+func (c *Client) TerminateUploadWithContext(ctx context.Context, url string) error {
+	if err := c.requireExtension(ctx, "termination", ErrTerminationNotSupported); err != nil {
+		return err
+	}
+
+	var method string
+
+	if !c.Config.OverridePatchMethod {
+		method = "DELETE"
+	} else {
+		method = "POST"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Length", "0")
+
+	if c.Config.OverridePatchMethod {
+		req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	}
+
+	res, err := c.Do(req)
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case 204:
+		return nil
+	case 404, 410:
+		return ErrUploadNotFound
+	case 412:
+		return ErrVersionMismatch
+	default:
+		return newClientError(res)
+	}
+}
+
+// uploadChunck sends data at offset. A non-negative length declares the size of an upload created
+// with a deferred length.
+func (c *Client) uploadChunck(ctx context.Context, url string, data []byte, offset int64, length int64) (int64, time.Time, error) {
 	var method string
 
 	if !c.Config.OverridePatchMethod {
@@ -179,56 +407,70 @@ func (c *Client) uploadChunck(url string, body io.Reader, size int64, offset int
 		method = "POST"
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
 
 	if err != nil {
-		return -1, err
+		return -1, time.Time{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/offset+octet-stream")
-	req.Header.Set("Content-Length", strconv.FormatInt(size, 10))
+	req.Header.Set("Content-Length", strconv.Itoa(len(data)))
 	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
 
+	if length >= 0 {
+		req.Header.Set("Upload-Length", strconv.FormatInt(length, 10))
+	}
+
 	if c.Config.OverridePatchMethod {
 		req.Header.Set("X-HTTP-Method-Override", "PATCH")
 	}
 
+	if c.Config.ChecksumAlgorithm != "" {
+		if err := c.checkChecksumSupport(ctx); err != nil {
+			return -1, time.Time{}, err
+		}
+
+		req.Header.Set("Upload-Checksum", c.Config.ChecksumAlgorithm.checksum(data))
+	}
+
 	res, err := c.Do(req)
 
 	if err != nil {
-		return -1, err
+		return -1, time.Time{}, err
 	}
 	defer res.Body.Close()
 
 	switch res.StatusCode {
 	case 204:
 		if newOffset, err := strconv.ParseInt(res.Header.Get("Upload-Offset"), 10, 64); err == nil {
-			return newOffset, nil
+			return newOffset, parseExpires(res), nil
 		} else {
-			return -1, err
+			return -1, time.Time{}, err
 		}
 	case 409:
-		return -1, ErrOffsetMismatch
+		return -1, time.Time{}, ErrOffsetMismatch
 	case 412:
-		return -1, ErrVersionMismatch
+		return -1, time.Time{}, ErrVersionMismatch
 	case 413:
-		return -1, ErrLargeUpload
+		return -1, time.Time{}, ErrLargeUpload
+	case 460:
+		return -1, time.Time{}, ErrChecksumMismatch
 	default:
-		return -1, newClientError(res)
+		return -1, time.Time{}, newClientError(res)
 	}
 }
 
-func (c *Client) getUploadOffset(url string) (int64, error) {
-	req, err := http.NewRequest("HEAD", url, nil)
+func (c *Client) getUploadOffset(ctx context.Context, url string) (int64, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 
 	if err != nil {
-		return -1, err
+		return -1, time.Time{}, err
 	}
 
 	res, err := c.Do(req)
 
 	if err != nil {
-		return -1, err
+		return -1, time.Time{}, err
 	}
 	defer res.Body.Close()
 
@@ -237,20 +479,49 @@ func (c *Client) getUploadOffset(url string) (int64, error) {
 		i, err := strconv.ParseInt(res.Header.Get("Upload-Offset"), 10, 64)
 
 		if err == nil {
-			return i, nil
+			return i, parseExpires(res), nil
 		} else {
-			return -1, err
+			return -1, time.Time{}, err
 		}
 	case 403, 404, 410:
 		// file doesn't exists.
-		return -1, ErrUploadNotFound
+		return -1, time.Time{}, ErrUploadNotFound
 	case 412:
-		return -1, ErrVersionMismatch
+		return -1, time.Time{}, ErrVersionMismatch
 	default:
-		return -1, newClientError(res)
+		return -1, time.Time{}, newClientError(res)
 	}
 }
 
+// parseExpires returns the upload expiration from the Upload-Expires header, if any.
+func parseExpires(res *http.Response) time.Time {
+	expires, _ := http.ParseTime(res.Header.Get("Upload-Expires"))
+	return expires
+}
+
+// splitHeader splits a comma separated header value.
+func splitHeader(value string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
 func newClientError(res *http.Response) ClientError {
 	body, _ := ioutil.ReadAll(res.Body)
 	return ClientError{
@@ -1,8 +1,11 @@
 package tus
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	netUrl "net/url"
@@ -13,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/tus/tusd/pkg/filestore"
 	tusd "github.com/tus/tusd/pkg/handler"
@@ -47,6 +51,64 @@ func (s *MockStore) Close() {
 	}
 }
 
+type MockUploadStore struct {
+	mu sync.Mutex
+	m  map[string]UploadRecord
+}
+
+func NewMockUploadStore() UploadStore {
+	return &MockUploadStore{
+		m: make(map[string]UploadRecord),
+	}
+}
+
+func (s *MockUploadStore) Get(ctx context.Context, fingerprint string) (*UploadRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.m[fingerprint]
+
+	if !ok {
+		return nil, ErrUploadNotFound
+	}
+
+	return &record, nil
+}
+
+func (s *MockUploadStore) Set(ctx context.Context, record *UploadRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.m[record.Fingerprint] = *record
+	return nil
+}
+
+func (s *MockUploadStore) Delete(ctx context.Context, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.m, fingerprint)
+	return nil
+}
+
+func (s *MockUploadStore) List(ctx context.Context) ([]*UploadRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []*UploadRecord
+
+	for _, record := range s.m {
+		r := record
+		records = append(records, &r)
+	}
+
+	return records, nil
+}
+
+func (s *MockUploadStore) Close() error {
+	return nil
+}
+
 type UploadTestSuite struct {
 	suite.Suite
 
@@ -60,25 +122,8 @@ func (s *UploadTestSuite) SetupSuite() {
 		Path: os.TempDir(),
 	}
 
-	composer := tusd.NewStoreComposer()
-
-	store.UseIn(composer)
-
-	handler, err := tusd.NewHandler(tusd.Config{
-		BasePath:                "/uploads/",
-		StoreComposer:           composer,
-		MaxSize:                 0,
-		NotifyCompleteUploads:   false,
-		NotifyTerminatedUploads: false,
-		RespectForwardedHeaders: true,
-	})
-
-	if err != nil {
-		panic(err)
-	}
-
 	s.store = store
-	s.ts = httptest.NewServer(http.StripPrefix("/uploads/", handler))
+	s.ts = httptest.NewServer(newTusdHandler(store))
 	s.url = fmt.Sprintf("%s/uploads/", s.ts.URL)
 }
 
@@ -300,16 +345,16 @@ func (s *UploadTestSuite) TestResumeUpload() {
 	s.Nil(err)
 	s.NotNil(uploader)
 
-	// This will stop the first upload.
-	go func() {
-		time.Sleep(250 * time.Millisecond)
-		uploader.Abort()
-	}()
+	// This will stop the first upload once its first chunk is acknowledged.
+	first := uploader
+	first.OnChunkComplete(func(ChunkEvent) {
+		first.Abort()
+	})
 
 	err = uploader.Upload()
 	s.Nil(err)
 
-	s.True(uploader.aborted)
+	s.True(uploader.IsAborted())
 
 	uploader, err = client.ResumeUpload(upload)
 	s.Nil(err)
@@ -327,6 +372,179 @@ func (s *UploadTestSuite) TestResumeUpload() {
 	s.EqualValues(1048576*150, fi.Size)
 }
 
+func (s *UploadTestSuite) TestTerminateUpload() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := &Config{
+		ChunkSize:           2 * 1024 * 1024,
+		Resume:              true,
+		OverridePatchMethod: false,
+		Store:               NewMockStore(),
+	}
+
+	client, err := NewClient(s.url, cfg)
+	s.Nil(err)
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+	upload.Fingerprint = "terminate"
+
+	uploader, err := client.CreateUpload(upload)
+	s.Nil(err)
+	s.NotNil(uploader)
+
+	_, found := cfg.Store.Get(client.storeKeyPrefix() + upload.Fingerprint)
+	s.True(found)
+
+	err = uploader.Terminate()
+	s.Nil(err)
+	s.True(uploader.IsAborted())
+
+	_, found = cfg.Store.Get(client.storeKeyPrefix() + upload.Fingerprint)
+	s.False(found)
+
+	_, err = s.store.GetUpload(ctx, uploadIDFromURL(uploader.url))
+	s.NotNil(err)
+
+	err = client.TerminateUpload(uploader.Url())
+	s.Equal(ErrUploadNotFound, err)
+}
+
+func (s *UploadTestSuite) TestTerminateUploadOverridePatchMethod() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := NewClient(s.url, nil)
+	s.Nil(err)
+
+	client.Config.OverridePatchMethod = true
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+
+	uploader, err := client.CreateUpload(upload)
+	s.Nil(err)
+	s.NotNil(uploader)
+
+	err = client.TerminateUpload(uploader.Url())
+	s.Nil(err)
+
+	_, err = s.store.GetUpload(ctx, uploadIDFromURL(uploader.url))
+	s.NotNil(err)
+}
+
+func (s *UploadTestSuite) TestParallelUpload() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data := make([]byte, 1048576*5+3) // 5 MB
+
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	cfg := &Config{
+		ChunkSize:           1024 * 1024,
+		Resume:              false,
+		OverridePatchMethod: false,
+	}
+
+	client, err := NewClient(s.url, cfg)
+	s.Nil(err)
+
+	// Hides the ReadAt method, so the parts share the seeker.
+	reader := struct{ io.ReadSeeker }{bytes.NewReader(data)}
+
+	upload := NewUpload(reader, int64(len(data)), Metadata{"filename": "parallel"}, "")
+
+	uploader, err := client.CreateParallelUpload(upload, 4)
+	s.Nil(err)
+	s.Len(uploader.Uploaders(), 4)
+
+	err = uploader.Upload()
+	s.Nil(err)
+	s.NotEmpty(uploader.Url())
+	s.EqualValues(len(data), uploader.Offset())
+
+	up, err := s.store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	s.Nil(err)
+
+	fi, err := up.GetInfo(ctx)
+	s.Nil(err)
+	s.True(fi.IsFinal)
+	s.EqualValues(len(data), fi.Offset)
+	s.Equal("parallel", fi.MetaData["filename"])
+
+	stored, err := up.GetReader(ctx)
+	s.Nil(err)
+
+	content, err := ioutil.ReadAll(stored)
+	s.Nil(err)
+	s.Equal(data, content)
+}
+
+func (s *UploadTestSuite) TestResumeParallelUpload() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := &Config{
+		ChunkSize:           1024,
+		Resume:              true,
+		OverridePatchMethod: false,
+		UploadStore:         NewMockUploadStore(),
+	}
+
+	client, err := NewClient(s.url, cfg)
+	s.Nil(err)
+
+	upload := NewUploadFromBytes(make([]byte, 10*1024))
+	upload.Fingerprint = "parallel"
+
+	uploader, err := client.CreateParallelUpload(upload, 2)
+	s.Nil(err)
+
+	// Upload a single chunk of the first part only.
+	err = uploader.Uploaders()[0].UploadChunck()
+	s.Nil(err)
+
+	created, err := client.loadRecord(ctx, upload.Fingerprint)
+	s.Nil(err)
+	s.False(created.CreatedAt.IsZero())
+
+	uploader, err = client.CreateParallelUpload(upload, 2)
+	s.Nil(err)
+	s.EqualValues(1024, uploader.Uploaders()[0].Offset())
+	s.EqualValues(0, uploader.Uploaders()[1].Offset())
+
+	err = uploader.Upload()
+	s.Nil(err)
+
+	record, err := client.loadRecord(ctx, upload.Fingerprint)
+	s.Nil(err)
+	s.Equal(uploader.Url(), record.Url)
+	s.Len(record.PartialUrls, 2)
+	s.True(created.CreatedAt.Equal(record.CreatedAt))
+
+	_, err = cfg.UploadStore.Get(ctx, client.storeKeyPrefix()+partialFingerprint(upload.Fingerprint, 0, 2))
+	s.Equal(ErrUploadNotFound, err)
+
+	up, err := s.store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	s.Nil(err)
+
+	fi, err := up.GetInfo(ctx)
+	s.Nil(err)
+	s.EqualValues(10*1024, fi.Offset)
+
+	// The finished upload isn't uploaded again.
+	uploader, err = client.CreateParallelUpload(upload, 2)
+	s.Nil(err)
+	s.Equal(record.Url, uploader.Url())
+	s.Empty(uploader.Uploaders())
+}
+
 func (s *UploadTestSuite) TestUploadLocation() {
 	client, err := NewClient(s.url, nil)
 	s.Nil(err)
@@ -367,6 +585,63 @@ func TestUploadTestSuite(t *testing.T) {
 	suite.Run(t, new(UploadTestSuite))
 }
 
+// newTusdHandler creates a tusd handler serving the store under /uploads/.
+func newTusdHandler(store filestore.FileStore) http.Handler {
+	composer := tusd.NewStoreComposer()
+
+	store.UseIn(composer)
+
+	handler, err := tusd.NewHandler(tusd.Config{
+		BasePath:                "/uploads/",
+		StoreComposer:           composer,
+		MaxSize:                 0,
+		NotifyCompleteUploads:   false,
+		NotifyTerminatedUploads: false,
+		RespectForwardedHeaders: true,
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	return http.StripPrefix("/uploads/", handler)
+}
+
+// newTestClient starts a tusd server, whose handler is wrapped by wrap if it isn't nil, and
+// returns a client sending chuncks of 4 bytes to it, changed by configure if it isn't nil,
+// the store of the server and a function closing the server.
+func newTestClient(t *testing.T, wrap func(http.Handler) http.Handler, configure func(*Config)) (*Client, filestore.FileStore, func()) {
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	h := newTusdHandler(store)
+
+	if wrap != nil {
+		h = wrap(h)
+	}
+
+	ts := httptest.NewServer(h)
+
+	cfg := DefaultConfig()
+	cfg.ChunkSize = 4
+
+	if configure != nil {
+		configure(cfg)
+	}
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	return client, store, ts.Close
+}
+
+// withStore enables resuming, saving the uploads in a MockStore.
+func withStore(cfg *Config) {
+	cfg.Resume = true
+	cfg.Store = NewMockStore()
+}
+
 func uploadIDFromURL(url string) string {
 	parts := strings.Split(url, "/")
 	return parts[len(parts)-1]
@@ -10,11 +10,24 @@ type Config struct {
 	ChunkSize int64
 	// Resume enables resumable upload.
 	Resume bool
-	// OverridePatchMethod allow to by pass proxies sendind a POST request instead of PATCH.
+	// OverridePatchMethod allow to by pass proxies sendind a POST request instead of PATCH or DELETE.
 	OverridePatchMethod bool
 	// Store map an upload's fingerprint with the corresponding upload URL.
-	// If Resume is true the Store is required.
+	// If Resume is true the Store or the UploadStore is required.
 	Store Store
+	// UploadStore keeps the state of each upload, taking precedence over Store.
+	UploadStore UploadStore
+	// Fingerprinter computes the fingerprint of the uploads when resuming is enabled,
+	// replacing the one they were created with. Nil keeps it.
+	Fingerprinter Fingerprinter
+	// StoreScope namespaces the stored uploads besides the endpoint url, e.g. by tenant,
+	// so a single store can be shared by several clients.
+	StoreScope string
+	// ChecksumAlgorithm enables the Checksum extension, sending an Upload-Checksum
+	// header computed with this algorithm on every chunk. Empty disables it.
+	ChecksumAlgorithm ChecksumAlgorithm
+	// RetryPolicy enables retrying failed chuncks. Nil disables it.
+	RetryPolicy *RetryPolicy
 	// Set custom header values used in all requests.
 	Header http.Header
 	// HTTP Client
@@ -39,9 +52,19 @@ func (c *Config) Validate() error {
 		return ErrChuckSize
 	}
 
-	if c.Resume && c.Store == nil {
+	if c.Resume && c.Store == nil && c.UploadStore == nil {
 		return ErrNilStore
 	}
 
+	if c.ChecksumAlgorithm != "" && !c.ChecksumAlgorithm.Valid() {
+		return ErrChecksumAlgorithm
+	}
+
+	if c.RetryPolicy != nil {
+		if err := c.RetryPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
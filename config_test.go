@@ -34,3 +34,10 @@ func TestConfingValid(t *testing.T) {
 	c := DefaultConfig()
 	assert.Nil(t, c.Validate())
 }
+
+func TestConfingUnknownChecksumAlgorithm(t *testing.T) {
+	c := DefaultConfig()
+	c.ChecksumAlgorithm = "sha3"
+
+	assert.Equal(t, ErrChecksumAlgorithm, c.Validate())
+}
@@ -0,0 +1,81 @@
+package tus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tus/tusd/pkg/filestore"
+)
+
+// extensionsHandler counts the requests by method and, if extensions isn't empty,
+// answers OPTIONS requests advertising only those extensions.
+func extensionsHandler(h http.Handler, extensions string, requests map[string]*int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n, ok := requests[r.Method]; ok {
+			atomic.AddInt32(n, 1)
+		}
+
+		if r.Method == "OPTIONS" && len(extensions) > 0 {
+			w.Header().Set("Tus-Resumable", ProtocolVersion)
+			w.Header().Set("Tus-Version", ProtocolVersion)
+			w.Header().Set("Tus-Extension", extensions)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func testCreationWithUpload(t *testing.T, extensions string, size int, chunkSize int64, expectedOffset int64, expectedPatches int32) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var patches int32
+
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	ts := httptest.NewServer(extensionsHandler(newTusdHandler(store), extensions, map[string]*int32{"PATCH": &patches}))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChunkSize = chunkSize
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes(make([]byte, size)))
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedOffset, uploader.Offset())
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedPatches, patches)
+
+	up, err := store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	assert.Nil(t, err)
+
+	fi, err := up.GetInfo(ctx)
+	assert.Nil(t, err)
+	assert.EqualValues(t, size, fi.Offset)
+}
+
+func TestCreationWithUpload(t *testing.T) {
+	testCreationWithUpload(t, "", 10, 1024, 10, 0)
+}
+
+func TestCreationWithUploadLargerThanChunk(t *testing.T) {
+	testCreationWithUpload(t, "", 10, 4, 4, 2)
+}
+
+func TestCreationWithUploadNotSupported(t *testing.T) {
+	testCreationWithUpload(t, "creation,termination", 10, 1024, 0, 1)
+}
@@ -10,12 +10,41 @@ var (
 	ErrNilLogger         = errors.New("logger can't be nil.")
 	ErrNilStore          = errors.New("store can't be nil if Resume is enable.")
 	ErrNilUpload         = errors.New("upload can't be nil.")
+	ErrNilClient         = errors.New("client can't be nil.")
+	ErrNilManager        = errors.New("manager can't be nil.")
 	ErrLargeUpload       = errors.New("upload body is to large.")
 	ErrVersionMismatch   = errors.New("protocol version mismatch.")
 	ErrOffsetMismatch    = errors.New("upload offset mismatch.")
 	ErrUploadNotFound    = errors.New("upload not found.")
 	ErrResumeNotEnabled  = errors.New("resuming not enabled.")
 	ErrFingerprintNotSet = errors.New("fingerprint not set.")
+	ErrListNotSupported  = errors.New("store can't list its records.")
+	ErrNotFile           = errors.New("upload isn't from a file.")
+	ErrUploadPaused      = errors.New("upload paused.")
+	ErrNotPaused         = errors.New("upload isn't paused.")
+	ErrUploadRunning     = errors.New("upload already running.")
+	ErrUploadFinished    = errors.New("upload already finished.")
+	ErrUploadAborted     = errors.New("upload aborted.")
+
+	ErrPartsCount   = errors.New("parts must be greater than zero.")
+	ErrConcurrency  = errors.New("concurrency must be greater than zero.")
+	ErrRetryPolicy  = errors.New("invalid retry policy.")
+	ErrSizeDeferred = errors.New("upload size is deferred.")
+
+	ErrQueueClosed   = errors.New("queue closed.")
+	ErrEntryNotFound = errors.New("queue entry not found.")
+	ErrEntryPending  = errors.New("queue entry is pending.")
+
+	ErrStreamNotSeekable   = errors.New("stream is not seekable.")
+	ErrOffsetOutsideBuffer = errors.New("offset is outside the buffered window of the stream.")
+
+	ErrTerminationNotSupported   = errors.New("termination not supported by the server.")
+	ErrConcatenationNotSupported = errors.New("concatenation not supported by the server.")
+	ErrDeferLengthNotSupported   = errors.New("deferred upload length not supported by the server.")
+
+	ErrChecksumAlgorithm    = errors.New("unknown checksum algorithm.")
+	ErrChecksumNotSupported = errors.New("checksum algorithm not supported by the server.")
+	ErrChecksumMismatch     = errors.New("upload checksum mismatch.")
 )
 
 type ClientError struct {
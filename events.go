@@ -0,0 +1,257 @@
+package tus
+
+import (
+	"sync"
+	"time"
+)
+
+// ProgressEvent reports the progress of an upload.
+type ProgressEvent struct {
+	Url string
+	// Offset is the amount of bytes acknowledged by the server.
+	Offset int64
+	// Size is the upload size, -1 while it's deferred.
+	Size int64
+	// BytesPerSecond is the average throughput since the upload started or resumed.
+	BytesPerSecond float64
+	// ETA is the estimated time to finish the upload, zero when unknown.
+	ETA time.Duration
+}
+
+// ChunkEvent reports a chunk acknowledged by the server.
+type ChunkEvent struct {
+	Url string
+	// Offset is where the chunk starts.
+	Offset int64
+	// Length is the amount of bytes of the chunk.
+	Length int64
+	// Duration is how long the chunk took to be sent and acknowledged.
+	Duration time.Duration
+}
+
+// uploadEvents holds the event handlers of an Uploader and delivers the events
+// without blocking the upload: handlers run in order on a goroutine which only exists
+// while there are events to deliver. Pending progress events are coalesced,
+// so slow handlers only see the latest progress.
+type uploadEvents struct {
+	mu sync.Mutex
+
+	onProgress      []func(ProgressEvent)
+	onChunkComplete []func(ChunkEvent)
+	onRetry         []func(RetryAttempt)
+	onComplete      []func(ProgressEvent)
+	onError         []func(error)
+	onAbort         []func(ProgressEvent)
+	subscribers     []chan Upload
+
+	queue   []queuedEvent
+	running bool
+	// stopped is closed once the running delivery goroutine returns.
+	stopped chan struct{}
+}
+
+type queuedEvent struct {
+	progress bool
+	deliver  func()
+}
+
+// OnProgress registers a handler called after each chunk with the upload progress.
+func (u *Uploader) OnProgress(fn func(ProgressEvent)) {
+	u.events.mu.Lock()
+	defer u.events.mu.Unlock()
+
+	u.events.onProgress = append(u.events.onProgress, fn)
+}
+
+// OnChunkComplete registers a handler called after each chunk acknowledged by the server.
+func (u *Uploader) OnChunkComplete(fn func(ChunkEvent)) {
+	u.events.mu.Lock()
+	defer u.events.mu.Unlock()
+
+	u.events.onChunkComplete = append(u.events.onChunkComplete, fn)
+}
+
+// OnRetry registers a handler called before a failed chunk is retried.
+func (u *Uploader) OnRetry(fn func(RetryAttempt)) {
+	u.events.mu.Lock()
+	defer u.events.mu.Unlock()
+
+	u.events.onRetry = append(u.events.onRetry, fn)
+}
+
+// OnComplete registers a handler called once the whole body was uploaded.
+func (u *Uploader) OnComplete(fn func(ProgressEvent)) {
+	u.events.mu.Lock()
+	defer u.events.mu.Unlock()
+
+	u.events.onComplete = append(u.events.onComplete, fn)
+}
+
+// OnError registers a handler called when Upload fails.
+func (u *Uploader) OnError(fn func(error)) {
+	u.events.mu.Lock()
+	defer u.events.mu.Unlock()
+
+	u.events.onError = append(u.events.onError, fn)
+}
+
+// OnAbort registers a handler called when the upload is aborted.
+func (u *Uploader) OnAbort(fn func(ProgressEvent)) {
+	u.events.mu.Lock()
+	defer u.events.mu.Unlock()
+
+	u.events.onAbort = append(u.events.onAbort, fn)
+}
+
+// post queues the delivery of an event, starting the delivery goroutine if needed.
+// Must be called holding mu.
+func (e *uploadEvents) post(progress bool, deliver func()) {
+	if n := len(e.queue); progress && n > 0 && e.queue[n-1].progress {
+		e.queue[n-1].deliver = deliver
+	} else {
+		e.queue = append(e.queue, queuedEvent{progress: progress, deliver: deliver})
+	}
+
+	if !e.running {
+		e.running = true
+		e.stopped = make(chan struct{})
+		go e.run(e.stopped)
+	}
+}
+
+// run delivers the queued events, returning once the queue is empty.
+func (e *uploadEvents) run(stopped chan struct{}) {
+	defer close(stopped)
+
+	for {
+		e.mu.Lock()
+
+		if len(e.queue) == 0 {
+			e.running = false
+			e.mu.Unlock()
+			return
+		}
+
+		event := e.queue[0]
+		e.queue = e.queue[1:]
+
+		e.mu.Unlock()
+
+		event.deliver()
+	}
+}
+
+func (e *uploadEvents) progress(event ProgressEvent, upload Upload) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if handlers, subscribers := e.onProgress, e.subscribers; len(handlers) > 0 || len(subscribers) > 0 {
+		e.post(true, func() {
+			for _, fn := range handlers {
+				fn(event)
+			}
+
+			for _, c := range subscribers {
+				select {
+				case c <- upload:
+				default:
+				}
+			}
+		})
+	}
+}
+
+func (e *uploadEvents) chunkComplete(event ChunkEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if handlers := e.onChunkComplete; len(handlers) > 0 {
+		e.post(false, func() {
+			for _, fn := range handlers {
+				fn(event)
+			}
+		})
+	}
+}
+
+func (e *uploadEvents) retry(event RetryAttempt) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if handlers := e.onRetry; len(handlers) > 0 {
+		e.post(false, func() {
+			for _, fn := range handlers {
+				fn(event)
+			}
+		})
+	}
+}
+
+func (e *uploadEvents) complete(event ProgressEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if handlers := e.onComplete; len(handlers) > 0 {
+		e.post(false, func() {
+			for _, fn := range handlers {
+				fn(event)
+			}
+		})
+	}
+}
+
+func (e *uploadEvents) error(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if handlers := e.onError; len(handlers) > 0 {
+		e.post(false, func() {
+			for _, fn := range handlers {
+				fn(err)
+			}
+		})
+	}
+}
+
+func (e *uploadEvents) abort(event ProgressEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if handlers := e.onAbort; len(handlers) > 0 {
+		e.post(false, func() {
+			for _, fn := range handlers {
+				fn(event)
+			}
+		})
+	}
+}
+
+// progressEvent returns the current progress of the upload.
+func (u *Uploader) progressEvent() ProgressEvent {
+	s := &u.stats
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := ProgressEvent{
+		Url:    s.url,
+		Offset: s.acked,
+		Size:   s.size,
+	}
+
+	if s.startedAt.IsZero() {
+		return event
+	}
+
+	elapsed := time.Since(s.startedAt).Seconds()
+
+	if sent := s.acked - s.startOffset; elapsed > 0 && sent > 0 {
+		event.BytesPerSecond = float64(sent) / elapsed
+
+		if s.size >= 0 {
+			event.ETA = time.Duration(float64(s.size-s.acked) / event.BytesPerSecond * float64(time.Second))
+		}
+	}
+
+	return event
+}
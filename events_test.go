@@ -0,0 +1,243 @@
+package tus
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadEvents(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+
+	client, _, closeServer := newTestClient(t, flaky(map[int32]int{2: 503}), withRetryPolicy(policy))
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	var mu sync.Mutex
+	var chunks []ChunkEvent
+	var retries []RetryAttempt
+	var last ProgressEvent
+
+	completed := make(chan ProgressEvent, 1)
+
+	uploader.OnChunkComplete(func(e ChunkEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		chunks = append(chunks, e)
+	})
+
+	uploader.OnRetry(func(a RetryAttempt) {
+		mu.Lock()
+		defer mu.Unlock()
+		retries = append(retries, a)
+	})
+
+	uploader.OnProgress(func(e ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		last = e
+	})
+
+	uploader.OnComplete(func(e ProgressEvent) {
+		completed <- e
+	})
+
+	uploader.OnError(func(err error) {
+		t.Errorf("unexpected error event: %s", err)
+	})
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+
+	select {
+	case e := <-completed:
+		assert.Equal(t, uploader.Url(), e.Url)
+		assert.EqualValues(t, 10, e.Offset)
+		assert.EqualValues(t, 10, e.Size)
+		assert.True(t, e.BytesPerSecond > 0)
+		assert.Zero(t, e.ETA)
+	case <-time.After(5 * time.Second):
+		t.Fatal("complete event wasn't delivered")
+	}
+
+	// Events are delivered in order, so the previous ones were delivered too.
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The first chunk is sent along with the creation request.
+	if assert.Len(t, chunks, 2) {
+		assert.Equal(t, uploader.Url(), chunks[0].Url)
+		assert.EqualValues(t, 4, chunks[0].Offset)
+		assert.EqualValues(t, 4, chunks[0].Length)
+		assert.EqualValues(t, 8, chunks[1].Offset)
+		assert.EqualValues(t, 2, chunks[1].Length)
+	}
+
+	if assert.Len(t, retries, 1) {
+		assert.EqualValues(t, 8, retries[0].Offset)
+	}
+
+	assert.EqualValues(t, 10, last.Offset)
+
+	// The delivery goroutine exits once the events are delivered.
+	waitEvents(t, &uploader.events)
+}
+
+func TestUploadErrorEvent(t *testing.T) {
+	client, _, closeServer := newTestClient(t, flaky(map[int32]int{1: 400}), nil)
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	failed := make(chan error, 1)
+
+	uploader.OnError(func(err error) {
+		failed <- err
+	})
+
+	err = uploader.Upload()
+	assert.NotNil(t, err)
+
+	select {
+	case e := <-failed:
+		assert.Equal(t, err, e)
+	case <-time.After(5 * time.Second):
+		t.Fatal("error event wasn't delivered")
+	}
+}
+
+func TestUploadAbortEvent(t *testing.T) {
+	uploader := NewUploader(nil, "http://tus.io/uploads/1", NewUploadFromBytes([]byte("1234567890")), 4)
+
+	aborted := make(chan ProgressEvent, 2)
+
+	uploader.OnAbort(func(e ProgressEvent) {
+		aborted <- e
+	})
+
+	uploader.Abort()
+	uploader.Abort()
+
+	select {
+	case e := <-aborted:
+		assert.EqualValues(t, 4, e.Offset)
+	case <-time.After(5 * time.Second):
+		t.Fatal("abort event wasn't delivered")
+	}
+
+	waitEvents(t, &uploader.events)
+	assert.Len(t, aborted, 0)
+}
+
+func TestSlowSubscribersDontBlockUpload(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, nil)
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	// Nobody reads from the channel.
+	uploader.NotifyUploadProgress(make(chan Upload))
+
+	release := make(chan struct{})
+	defer close(release)
+
+	uploader.OnProgress(func(ProgressEvent) {
+		<-release
+	})
+
+	done := make(chan error)
+
+	go func() {
+		done <- uploader.Upload()
+	}()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+		assert.EqualValues(t, 10, uploader.Offset())
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload was blocked by the subscribers")
+	}
+}
+
+func TestEventsCoalesceProgress(t *testing.T) {
+	var e uploadEvents
+
+	delivering := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	var offsets []int64
+	var errs []error
+
+	e.onProgress = []func(ProgressEvent){func(p ProgressEvent) {
+		select {
+		case delivering <- struct{}{}:
+		default:
+		}
+
+		<-release
+
+		mu.Lock()
+		defer mu.Unlock()
+		offsets = append(offsets, p.Offset)
+	}}
+
+	e.onError = []func(error){func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}}
+
+	// The first event is being delivered while the others are queued.
+	e.progress(ProgressEvent{Offset: 1}, Upload{})
+	<-delivering
+
+	for i := int64(2); i <= 5; i++ {
+		e.progress(ProgressEvent{Offset: i}, Upload{})
+	}
+
+	e.error(errors.New("failed"))
+	e.progress(ProgressEvent{Offset: 6}, Upload{})
+	e.progress(ProgressEvent{Offset: 7}, Upload{})
+
+	close(release)
+
+	waitEvents(t, &e)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []int64{1, 5, 7}, offsets)
+	assert.Len(t, errs, 1)
+}
+
+// waitEvents waits the delivery goroutine to finish.
+func waitEvents(t *testing.T, e *uploadEvents) {
+	e.mu.Lock()
+	stopped := e.stopped
+	e.mu.Unlock()
+
+	if stopped == nil {
+		return
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("events weren't delivered")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	assert.False(t, e.running)
+}
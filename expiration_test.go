@@ -0,0 +1,133 @@
+package tus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// expiresHandler informs the expiration in every response.
+func expiresHandler(h http.Handler, expires *time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Upload-Expires", expires.UTC().Format(http.TimeFormat))
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestUploadExpires(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	client, _, closeServer := newTestClient(t, func(h http.Handler) http.Handler {
+		return extensionsHandler(expiresHandler(h, &expires), "", nil)
+	}, withStore)
+	defer closeServer()
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+	upload.Fingerprint = "expires"
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+	assert.True(t, expires.Equal(uploader.Expires()))
+
+	record, err := client.loadRecord(context.Background(), upload.Fingerprint)
+	assert.Nil(t, err)
+	assert.True(t, expires.Equal(record.ExpiresAt))
+
+	// The expiration is extended by the server on PATCH.
+	expires = expires.Add(time.Hour)
+
+	err = uploader.UploadChunck()
+	assert.Nil(t, err)
+	assert.True(t, expires.Equal(uploader.Expires()))
+
+	record, err = client.loadRecord(context.Background(), upload.Fingerprint)
+	assert.Nil(t, err)
+	assert.True(t, expires.Equal(record.ExpiresAt))
+
+	// And on HEAD.
+	expires = expires.Add(time.Hour)
+
+	uploader, err = client.ResumeUpload(upload)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 8, uploader.Offset())
+	assert.True(t, expires.Equal(uploader.Expires()))
+
+	record, err = client.loadRecord(context.Background(), upload.Fingerprint)
+	assert.Nil(t, err)
+	assert.True(t, expires.Equal(record.ExpiresAt))
+}
+
+func TestResumeExpiredUpload(t *testing.T) {
+	var heads, posts int32
+
+	expires := time.Now().Add(time.Hour)
+
+	client, _, closeServer := newTestClient(t, func(h http.Handler) http.Handler {
+		return extensionsHandler(expiresHandler(h, &expires), "", map[string]*int32{"HEAD": &heads, "POST": &posts})
+	}, withStore)
+	defer closeServer()
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+	upload.Fingerprint = "expired"
+
+	err := client.saveRecord(context.Background(), &UploadRecord{
+		Fingerprint: upload.Fingerprint,
+		Url:         client.Url + "expired",
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	assert.Nil(t, err)
+
+	_, err = client.ResumeUpload(upload)
+	assert.Equal(t, ErrUploadNotFound, err)
+	assert.EqualValues(t, 0, heads)
+
+	_, found := client.Config.Store.Get(client.storeKeyPrefix() + upload.Fingerprint)
+	assert.False(t, found)
+
+	_, found = client.Config.Store.Get(client.storeKeyPrefix() + upload.Fingerprint + expiresKeySuffix)
+	assert.False(t, found)
+
+	err = client.saveRecord(context.Background(), &UploadRecord{
+		Fingerprint: upload.Fingerprint,
+		Url:         client.Url + "expired",
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	assert.Nil(t, err)
+
+	uploader, err := client.CreateOrResumeUpload(upload)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, heads)
+	assert.EqualValues(t, 1, posts)
+
+	record, err := client.loadRecord(context.Background(), upload.Fingerprint)
+	assert.Nil(t, err)
+	assert.Equal(t, uploader.Url(), record.Url)
+}
+
+func TestResumeTerminatedUpload(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+
+	client, _, closeServer := newTestClient(t, func(h http.Handler) http.Handler {
+		return extensionsHandler(expiresHandler(h, &expires), "", nil)
+	}, withStore)
+	defer closeServer()
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+	upload.Fingerprint = "terminated"
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+
+	err = client.TerminateUpload(uploader.Url())
+	assert.Nil(t, err)
+
+	// The server doesn't know the upload anymore.
+	_, err = client.ResumeUpload(upload)
+	assert.Equal(t, ErrUploadNotFound, err)
+
+	_, found := client.Config.Store.Get(client.storeKeyPrefix() + upload.Fingerprint)
+	assert.False(t, found)
+}
@@ -0,0 +1,150 @@
+package tus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultSampleSize is the size of each block hashed by SampledFingerprinter.
+const DefaultSampleSize = 1024 * 1024
+
+// Fingerprinter computes the fingerprint identifying an upload in the Store.
+type Fingerprinter interface {
+	Fingerprint(u *Upload) (string, error)
+}
+
+// PathFingerprinter fingerprints uploads from files by their absolute path,
+// file identity (device and inode, or volume and file index on Windows) and size.
+// It keeps identifying a file after it's touched, but not after it's copied.
+type PathFingerprinter struct{}
+
+func (PathFingerprinter) Fingerprint(u *Upload) (string, error) {
+	f, ok := u.stream.(*os.File)
+
+	if !ok {
+		return "", ErrNotFile
+	}
+
+	path, err := filepath.Abs(f.Name())
+
+	if err != nil {
+		return "", err
+	}
+
+	fi, err := f.Stat()
+
+	if err != nil {
+		return "", err
+	}
+
+	id, err := fileID(f, fi)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%s-%d", path, id, fi.Size()), nil
+}
+
+// SHA256Fingerprinter fingerprints uploads by the sha256 hash of their whole content.
+// It keeps identifying a file after it's copied or touched, but reads it entirely.
+type SHA256Fingerprinter struct{}
+
+func (SHA256Fingerprinter) Fingerprint(u *Upload) (string, error) {
+	h := sha256.New()
+
+	if err := u.hashRange(h, 0, -1); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sha256-%s", hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// SampledFingerprinter fingerprints uploads by the size and the sha256 hash of three blocks,
+// from the head, middle and tail of their content, so huge files are fingerprinted quickly.
+// Files up to three blocks are hashed entirely.
+type SampledFingerprinter struct {
+	// SampleSize is the size of each block, DefaultSampleSize when zero.
+	SampleSize int64
+}
+
+func (s SampledFingerprinter) Fingerprint(u *Upload) (string, error) {
+	if u.sizeIsDeferred {
+		return "", ErrSizeDeferred
+	}
+
+	size := s.SampleSize
+
+	if size <= 0 {
+		size = DefaultSampleSize
+	}
+
+	h := sha256.New()
+
+	if u.size <= 3*size {
+		if err := u.hashRange(h, 0, u.size); err != nil {
+			return "", err
+		}
+	} else {
+		for _, offset := range []int64{0, (u.size - size) / 2, u.size - size} {
+			if err := u.hashRange(h, offset, size); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return fmt.Sprintf("sampled-sha256-%d-%d-%s", size, u.size, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// hashRange writes length bytes of the upload from offset to h, or up to its end when length is negative.
+func (u *Upload) hashRange(h hash.Hash, offset, length int64) error {
+	if !u.seekable() {
+		return ErrStreamNotSeekable
+	}
+
+	if _, err := u.stream.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	var r io.Reader = u.stream
+
+	if length >= 0 {
+		r = io.LimitReader(r, length)
+	}
+
+	n, err := io.Copy(h, r)
+
+	if err != nil {
+		return err
+	}
+
+	if length >= 0 && n < length {
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
+
+// fingerprint replaces the upload fingerprint by the one of the configured Fingerprinter.
+// It's computed once per upload and only when resuming is enabled.
+func (c *Client) fingerprint(u *Upload) error {
+	if !c.Config.Resume || c.Config.Fingerprinter == nil || u.fingerprinted {
+		return nil
+	}
+
+	fingerprint, err := c.Config.Fingerprinter.Fingerprint(u)
+
+	if err != nil {
+		return err
+	}
+
+	u.Fingerprint = fingerprint
+	u.fingerprinted = true
+
+	return nil
+}
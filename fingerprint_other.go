@@ -0,0 +1,13 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !illumos && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!illumos,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package tus
+
+import (
+	"os"
+)
+
+// fileID returns the modification time, as the platform doesn't expose a file identity.
+func fileID(f *os.File, fi os.FileInfo) (string, error) {
+	return fi.ModTime().UTC().Format("20060102150405.000000000"), nil
+}
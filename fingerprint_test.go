@@ -0,0 +1,185 @@
+package tus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tus/tusd/pkg/filestore"
+)
+
+func writeTempFile(t *testing.T, dir, name string, data []byte) *os.File {
+	path := filepath.Join(dir, name)
+
+	err := ioutil.WriteFile(path, data, 0600)
+	assert.Nil(t, err)
+
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+
+	return f
+}
+
+func fingerprintFile(t *testing.T, fp Fingerprinter, f *os.File) string {
+	u, err := NewUploadFromFile(f)
+	assert.Nil(t, err)
+
+	fingerprint, err := fp.Fingerprint(u)
+	assert.Nil(t, err)
+
+	return fingerprint
+}
+
+func TestPathFingerprinter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fingerprint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "other"), 0700)
+	assert.Nil(t, err)
+
+	f := writeTempFile(t, dir, "file.txt", []byte("1234567890"))
+	defer f.Close()
+
+	copied := writeTempFile(t, dir, "other/file.txt", []byte("1234567890"))
+	defer copied.Close()
+
+	fingerprint := fingerprintFile(t, PathFingerprinter{}, f)
+	assert.True(t, strings.HasPrefix(fingerprint, f.Name()))
+
+	// Touching the file keeps the fingerprint.
+	err = os.Chtimes(f.Name(), time.Now(), time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, fingerprint, fingerprintFile(t, PathFingerprinter{}, f))
+
+	// Files with the same name and size in other directory don't collide.
+	assert.NotEqual(t, fingerprint, fingerprintFile(t, PathFingerprinter{}, copied))
+
+	_, err = PathFingerprinter{}.Fingerprint(NewUploadFromBytes([]byte("1234567890")))
+	assert.Equal(t, ErrNotFile, err)
+}
+
+func TestSHA256Fingerprinter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fingerprint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := writeTempFile(t, dir, "file.txt", []byte("1234567890"))
+	defer f.Close()
+
+	copied := writeTempFile(t, dir, "copy.txt", []byte("1234567890"))
+	defer copied.Close()
+
+	changed := writeTempFile(t, dir, "changed.txt", []byte("1234567891"))
+	defer changed.Close()
+
+	fingerprint := fingerprintFile(t, SHA256Fingerprinter{}, f)
+	assert.Equal(t, "sha256-c775e7b757ede630cd0aa1113bd102661ab38829ca52a6422ab782862f268646", fingerprint)
+	assert.Equal(t, fingerprint, fingerprintFile(t, SHA256Fingerprinter{}, copied))
+	assert.NotEqual(t, fingerprint, fingerprintFile(t, SHA256Fingerprinter{}, changed))
+
+	stored, err := SHA256Fingerprinter{}.Fingerprint(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+	assert.Equal(t, fingerprint, stored)
+
+	// Hides the Seek method from NewUpload.
+	reader := struct{ io.Reader }{bytes.NewReader([]byte("1234567890"))}
+
+	_, err = SHA256Fingerprinter{}.Fingerprint(NewUpload(reader, 10, nil, ""))
+	assert.Equal(t, ErrStreamNotSeekable, err)
+}
+
+func TestSampledFingerprinter(t *testing.T) {
+	fp := SampledFingerprinter{SampleSize: 4}
+
+	data := []byte("0123456789abcdefghij")
+
+	fingerprint := func(data []byte) string {
+		s, err := fp.Fingerprint(NewUploadFromBytes(data))
+		assert.Nil(t, err)
+		return s
+	}
+
+	sampled := fingerprint(data)
+	assert.True(t, strings.HasPrefix(sampled, "sampled-sha256-4-20-"))
+
+	// Only the head, middle and tail blocks are hashed.
+	changed := append([]byte{}, data...)
+	changed[5] = 'x'
+	assert.Equal(t, sampled, fingerprint(changed))
+
+	for _, i := range []int{0, 8, 19} {
+		changed := append([]byte{}, data...)
+		changed[i] = 'x'
+		assert.NotEqual(t, sampled, fingerprint(changed), i)
+	}
+
+	// Small uploads are hashed entirely.
+	small := fingerprint(data[:12])
+
+	changed = append([]byte{}, data[:12]...)
+	changed[5] = 'x'
+	assert.NotEqual(t, small, fingerprint(changed))
+
+	_, err := fp.Fingerprint(NewUploadWithDeferredLength(bytes.NewReader(data), nil, ""))
+	assert.Equal(t, ErrSizeDeferred, err)
+}
+
+func TestResumeUploadWithFingerprinter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fingerprint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	ts := httptest.NewServer(newTusdHandler(store))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChunkSize = 4
+	cfg.Resume = true
+	cfg.Store = NewMockStore()
+	cfg.Fingerprinter = SHA256Fingerprinter{}
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	f := writeTempFile(t, dir, "file.txt", []byte("1234567890"))
+	defer f.Close()
+
+	upload, err := NewUploadFromFile(f)
+	assert.Nil(t, err)
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(upload.Fingerprint, "sha256-"))
+
+	err = uploader.UploadChunck()
+	assert.Nil(t, err)
+
+	// A copy of the file resumes the same upload.
+	copied := writeTempFile(t, dir, "copy.txt", []byte("1234567890"))
+	defer copied.Close()
+
+	upload, err = NewUploadFromFile(copied)
+	assert.Nil(t, err)
+
+	resumed, err := client.ResumeUpload(upload)
+	assert.Nil(t, err)
+	assert.Equal(t, uploader.Url(), resumed.Url())
+	assert.EqualValues(t, 8, resumed.Offset())
+
+	err = resumed.Upload()
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, resumed.Offset())
+}
@@ -0,0 +1,21 @@
+//go:build aix || darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd illumos linux netbsd openbsd solaris
+
+package tus
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode of the file.
+func fileID(f *os.File, fi os.FileInfo) (string, error) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+
+	if !ok {
+		return "", ErrNotFile
+	}
+
+	return fmt.Sprintf("%d:%d", uint64(st.Dev), uint64(st.Ino)), nil
+}
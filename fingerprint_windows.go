@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package tus
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID returns the volume serial number and file index of the file.
+func fileID(f *os.File, fi os.FileInfo) (string, error) {
+	var d syscall.ByHandleFileInformation
+
+	if err := syscall.GetFileInformationByHandle(syscall.Handle(f.Fd()), &d); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x:%x%08x", d.VolumeSerialNumber, d.FileIndexHigh, d.FileIndexLow), nil
+}
@@ -3,7 +3,12 @@ module github.com/eventials/go-tus
 go 1.13
 
 require (
-	github.com/stretchr/testify v1.5.1
+	github.com/alicebob/miniredis/v2 v2.14.3
+	github.com/bradfitz/gomemcache v0.0.0-20230905024940-24af94b03874
+	github.com/gomodule/redigo v1.8.9
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/stretchr/testify v1.7.0
 	github.com/syndtr/goleveldb v1.0.0
 	github.com/tus/tusd v1.1.0
+	go.etcd.io/bbolt v1.3.6
 )
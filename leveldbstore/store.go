@@ -37,5 +37,19 @@ func (s *LeveldbStore) Delete(fingerprint string) {
 }
 
 func (s *LeveldbStore) Close() {
-	s.Close()
+	s.db.Close()
+}
+
+// Keys returns the fingerprints in the store.
+func (s *LeveldbStore) Keys() []string {
+	var keys []string
+
+	iter := s.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+
+	return keys
 }
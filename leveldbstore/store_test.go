@@ -0,0 +1,71 @@
+package leveldbstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eventials/go-tus"
+	"github.com/eventials/go-tus/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeveldbStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "leveldbstore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewLeveldbStore(dir)
+	assert.Nil(t, err)
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("a", "http://tus.io/uploads/1")
+
+	url, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/1", url)
+	assert.Equal(t, []string{"a"}, s.(*LeveldbStore).Keys())
+
+	s.Delete("a")
+
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("b", "http://tus.io/uploads/2")
+	s.Close()
+
+	// The database lock was released on Close.
+	s, err = NewLeveldbStore(dir)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	url, ok = s.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/2", url)
+}
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "leveldbstore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var n int
+
+	storetest.TestStore(t, func(t *testing.T) tus.Store {
+		n++
+		s, err := NewLeveldbStore(filepath.Join(dir, fmt.Sprint(n)))
+		assert.Nil(t, err)
+		return s
+	})
+
+	storetest.TestUploadStore(t, func(t *testing.T) tus.UploadStore {
+		n++
+		s, err := NewLeveldbUploadStore(filepath.Join(dir, fmt.Sprint(n)))
+		assert.Nil(t, err)
+		return s
+	})
+}
@@ -0,0 +1,75 @@
+package leveldbstore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/eventials/go-tus"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// LeveldbUploadStore implements tus.UploadStore, reporting the database failures.
+type LeveldbUploadStore struct {
+	db *leveldb.DB
+}
+
+// NewLeveldbUploadStore opens the database at path, creating it if needed.
+func NewLeveldbUploadStore(path string) (tus.UploadStore, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LeveldbUploadStore{db: db}, nil
+}
+
+func (s *LeveldbUploadStore) Get(ctx context.Context, fingerprint string) (*tus.UploadRecord, error) {
+	value, err := s.db.Get([]byte(fingerprint), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, tus.ErrUploadNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var record tus.UploadRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (s *LeveldbUploadStore) Set(ctx context.Context, record *tus.UploadRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put([]byte(record.Fingerprint), value, nil)
+}
+
+func (s *LeveldbUploadStore) Delete(ctx context.Context, fingerprint string) error {
+	return s.db.Delete([]byte(fingerprint), nil)
+}
+
+func (s *LeveldbUploadStore) List(ctx context.Context) ([]*tus.UploadRecord, error) {
+	var records []*tus.UploadRecord
+
+	iter := s.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		var record tus.UploadRecord
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			return nil, err
+		}
+
+		records = append(records, &record)
+	}
+
+	return records, iter.Error()
+}
+
+func (s *LeveldbUploadStore) Close() error {
+	return s.db.Close()
+}
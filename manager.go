@@ -0,0 +1,517 @@
+package tus
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Manager uploads many uploads with a bounded number of concurrent uploads.
+// Queued uploads with higher priority are started first, and uploads with the same
+// priority are started in the order they were added.
+// A paused job releases its worker until it's resumed, when it's queued again.
+type Manager struct {
+	client      *Client
+	concurrency int
+
+	mu      sync.Mutex
+	queue   jobQueue
+	jobs    []*Job
+	running int
+	seq     uint64
+}
+
+// Job is an upload added to a Manager.
+type Job struct {
+	manager  *Manager
+	open     func() (*Upload, error)
+	priority int
+	seq      uint64
+	index    int
+
+	// Guarded by the manager mutex, cancel is set while the job is running.
+	upload    *Upload
+	size      int64
+	uploader  *Uploader
+	cancel    context.CancelFunc
+	paused    bool
+	resumed   bool
+	cancelled bool
+	finished  bool
+	err       error
+
+	done chan struct{}
+}
+
+// JobResult is the outcome of a Job.
+type JobResult struct {
+	Job    *Job
+	Upload *Upload
+	// Url is the upload url, empty if it wasn't created.
+	Url string
+	// Err is nil if the upload finished, context.Canceled if the job was cancelled
+	// and ErrUploadAborted if its uploader was aborted.
+	Err error
+}
+
+// ManagerProgress is the aggregate progress of the jobs of a Manager.
+type ManagerProgress struct {
+	Jobs      int
+	Queued    int
+	Running   int
+	Paused    int
+	Completed int
+	Failed    int
+	// Offset is the amount of bytes acknowledged by the server for all jobs.
+	Offset int64
+	// Size is the size of all jobs whose size is known, it isn't deferred and
+	// the jobs added with AddFunc were already opened.
+	Size int64
+}
+
+// NewManager creates a new Manager running up to concurrency uploads at once.
+func NewManager(client *Client, concurrency int) (*Manager, error) {
+	if client == nil {
+		return nil, ErrNilClient
+	}
+
+	if concurrency < 1 {
+		return nil, ErrConcurrency
+	}
+
+	return &Manager{
+		client:      client,
+		concurrency: concurrency,
+	}, nil
+}
+
+// Add queues the upload with the given priority, starting it as soon as there is a free worker.
+func (m *Manager) Add(u *Upload, priority int) *Job {
+	return m.add(u, nil, priority)
+}
+
+// AddFunc queues an upload created by open once the job is started, so the resources held by
+// the upload, such as an open file, are only held while the job runs.
+// The job fails with the error returned by open.
+func (m *Manager) AddFunc(open func() (*Upload, error), priority int) *Job {
+	return m.add(nil, open, priority)
+}
+
+func (m *Manager) add(u *Upload, open func() (*Upload, error), priority int) *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+
+	j := &Job{
+		manager:  m,
+		open:     open,
+		upload:   u,
+		size:     -1,
+		priority: priority,
+		seq:      m.seq,
+		done:     make(chan struct{}),
+	}
+
+	if u != nil {
+		j.size = u.Size()
+	}
+
+	m.jobs = append(m.jobs, j)
+	m.push(j)
+
+	return j
+}
+
+// push queues the job, starting a worker if there is a free one. Must be called holding mu.
+func (m *Manager) push(j *Job) {
+	heap.Push(&m.queue, j)
+
+	// Workers exist only while there are queued jobs.
+	if m.running < m.concurrency {
+		m.running++
+		go m.work()
+	}
+}
+
+// Jobs returns all jobs added to the manager.
+func (m *Manager) Jobs() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*Job(nil), m.jobs...)
+}
+
+// Progress returns the aggregate progress of all jobs.
+func (m *Manager) Progress() ManagerProgress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := ManagerProgress{
+		Jobs: len(m.jobs),
+	}
+
+	for _, j := range m.jobs {
+		size := j.size
+
+		// The upload is only read through the uploader once it's running.
+		if j.uploader != nil {
+			stats := j.uploader.Stats()
+			size = stats.Size
+			p.Offset += stats.BytesAcked
+		}
+
+		if size >= 0 {
+			p.Size += size
+		}
+
+		switch {
+		case j.finished && j.err == nil:
+			p.Completed++
+		case j.finished:
+			p.Failed++
+		case j.paused:
+			p.Paused++
+		case j.cancel == nil:
+			p.Queued++
+		default:
+			p.Running++
+		}
+	}
+
+	return p
+}
+
+// Wait waits for all jobs added so far and returns their results in the order they were added.
+// Paused jobs are waited until they're resumed and finished.
+func (m *Manager) Wait() []JobResult {
+	jobs := m.Jobs()
+	results := make([]JobResult, len(jobs))
+
+	for i, j := range jobs {
+		results[i] = j.Wait()
+	}
+
+	return results
+}
+
+// Cancel cancels all jobs not finished yet.
+func (m *Manager) Cancel() {
+	for _, j := range m.Jobs() {
+		j.Cancel()
+	}
+}
+
+// work runs the queued jobs, returning once the queue is empty.
+func (m *Manager) work() {
+	for {
+		m.mu.Lock()
+
+		if m.queue.Len() == 0 {
+			m.running--
+			m.mu.Unlock()
+			return
+		}
+
+		j := heap.Pop(&m.queue).(*Job)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		j.cancel = cancel
+
+		m.mu.Unlock()
+
+		j.run(ctx)
+		cancel()
+	}
+}
+
+// run creates or resumes the upload and uploads it, or continues the upload of a resumed job.
+func (j *Job) run(ctx context.Context) {
+	m := j.manager
+
+	m.mu.Lock()
+	upload, uploader := j.upload, j.uploader
+	m.mu.Unlock()
+
+	// A resumed job continues the upload of its uploader.
+	if uploader != nil {
+		if uploader.State() == StatePaused {
+			j.stop(uploader, uploader.ResumeWithContext(ctx))
+		} else {
+			j.stop(uploader, uploader.UploadWithContext(ctx))
+		}
+
+		return
+	}
+
+	if upload == nil {
+		u, err := j.open()
+
+		if err != nil {
+			j.finish(err)
+			return
+		}
+
+		m.mu.Lock()
+		j.upload = u
+		j.size = u.Size()
+		m.mu.Unlock()
+
+		upload = u
+	}
+
+	uploader, err := m.client.CreateOrResumeUploadWithContext(ctx, upload)
+
+	if err != nil {
+		j.finish(err)
+		return
+	}
+
+	m.mu.Lock()
+	j.uploader = uploader
+
+	// Paused while the upload was created.
+	if j.paused {
+		uploader.Pause()
+	}
+
+	m.mu.Unlock()
+
+	j.stop(uploader, uploader.UploadWithContext(ctx))
+}
+
+// stop records the outcome of the upload process. A paused job releases its worker
+// until it's resumed, and is queued again if it was resumed while its uploader was stopping.
+func (j *Job) stop(uploader *Uploader, err error) {
+	m := j.manager
+
+	if err == nil && uploader.IsAborted() {
+		err = ErrUploadAborted
+	}
+
+	if err != ErrUploadPaused {
+		j.finish(err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if j.cancelled {
+		j.finishLocked(context.Canceled)
+		return
+	}
+
+	j.cancel = nil
+
+	if j.resumed {
+		j.resumed = false
+		m.push(j)
+		return
+	}
+
+	// Paused by Pause, or through the uploader.
+	j.paused = true
+}
+
+// finish records the result of the job. Must not be called holding the manager mutex.
+func (j *Job) finish(err error) {
+	m := j.manager
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j.finishLocked(err)
+}
+
+// finishLocked records the result of the job. Must be called holding the manager mutex.
+func (j *Job) finishLocked(err error) {
+	if j.cancelled {
+		err = context.Canceled
+	}
+
+	j.finished = true
+	j.paused = false
+	j.err = err
+
+	close(j.done)
+}
+
+// Upload returns the upload of the job, nil until a job added with AddFunc is started.
+func (j *Job) Upload() *Upload {
+	j.manager.mu.Lock()
+	defer j.manager.mu.Unlock()
+
+	return j.upload
+}
+
+// Priority returns the priority of the job.
+func (j *Job) Priority() int {
+	return j.priority
+}
+
+// Uploader returns the uploader of the job, nil until the upload is created or resumed.
+func (j *Job) Uploader() *Uploader {
+	j.manager.mu.Lock()
+	defer j.manager.mu.Unlock()
+
+	return j.uploader
+}
+
+// Done returns a channel closed once the job is finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait waits for the job to finish and returns its result.
+func (j *Job) Wait() JobResult {
+	<-j.done
+
+	j.manager.mu.Lock()
+	defer j.manager.mu.Unlock()
+
+	r := JobResult{
+		Job:    j,
+		Upload: j.upload,
+		Err:    j.err,
+	}
+
+	if j.uploader != nil {
+		r.Url = j.uploader.Url()
+	}
+
+	return r
+}
+
+// Cancel cancels the job. A queued job is removed from the queue and
+// a running job has its current chunck interrupted.
+func (j *Job) Cancel() {
+	m := j.manager
+
+	m.mu.Lock()
+
+	if j.finished || j.cancelled {
+		m.mu.Unlock()
+		return
+	}
+
+	j.cancelled = true
+
+	if j.cancel != nil {
+		j.cancel()
+		m.mu.Unlock()
+		return
+	}
+
+	if j.index >= 0 {
+		heap.Remove(&m.queue, j.index)
+	}
+
+	m.mu.Unlock()
+
+	j.finish(context.Canceled)
+}
+
+// Pause pauses the job, releasing its worker. A queued job is removed from the queue
+// and a running job has its uploader paused, interrupting the current chunck.
+// The job is finished only after it's resumed with Resume.
+func (j *Job) Pause() error {
+	m := j.manager
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if j.finished || j.cancelled {
+		return ErrUploadFinished
+	}
+
+	if j.paused {
+		return nil
+	}
+
+	j.paused = true
+	j.resumed = false
+
+	if j.cancel == nil {
+		if j.index >= 0 {
+			heap.Remove(&m.queue, j.index)
+		}
+
+		return nil
+	}
+
+	// While the upload is created there is no uploader yet, run pauses it later.
+	if j.uploader != nil {
+		j.uploader.Pause()
+	}
+
+	return nil
+}
+
+// Resume queues a paused job again, its upload continues from the offset the server has.
+func (j *Job) Resume() error {
+	m := j.manager
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !j.paused {
+		return ErrNotPaused
+	}
+
+	j.paused = false
+
+	// A running job is queued again by stop once its uploader returns.
+	if j.cancel != nil {
+		j.resumed = true
+	} else {
+		m.push(j)
+	}
+
+	return nil
+}
+
+// IsPaused returns true if the job is paused.
+func (j *Job) IsPaused() bool {
+	j.manager.mu.Lock()
+	defer j.manager.mu.Unlock()
+
+	return j.paused
+}
+
+// jobQueue is a priority queue of jobs, implementing heap.Interface.
+type jobQueue []*Job
+
+func (q jobQueue) Len() int {
+	return len(q)
+}
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *jobQueue) Push(x interface{}) {
+	j := x.(*Job)
+	j.index = len(*q)
+	*q = append(*q, j)
+}
+
+func (q *jobQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	j.index = -1
+	*q = old[:n-1]
+	return j
+}
@@ -0,0 +1,447 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedHandler records the order the uploads are created, sending their names to reached
+// when their creation request arrives, and blocks the creation of the uploads named in gates
+// until their channel is closed.
+type gatedHandler struct {
+	h       http.Handler
+	gates   map[string]chan struct{}
+	reached chan string
+
+	mu       sync.Mutex
+	created  []string
+	inflight int32
+	max      int32
+}
+
+func newGatedHandler(gates map[string]chan struct{}) *gatedHandler {
+	return &gatedHandler{
+		gates: gates,
+		// Buffered for all uploads of a test, so the creations aren't blocked.
+		reached: make(chan string, 16),
+	}
+}
+
+func (g *gatedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		g.h.ServeHTTP(w, r)
+		return
+	}
+
+	var name string
+
+	for _, filename := range []string{"blocker", "low", "mid", "high", "a", "b", "c", "d", "e", "f"} {
+		if r.Header.Get("Upload-Metadata") == "filename "+b64encode(filename) {
+			name = filename
+		}
+	}
+
+	g.mu.Lock()
+	g.created = append(g.created, name)
+	gate := g.gates[name]
+	g.mu.Unlock()
+
+	n := atomic.AddInt32(&g.inflight, 1)
+	defer atomic.AddInt32(&g.inflight, -1)
+
+	for {
+		max := atomic.LoadInt32(&g.max)
+
+		if n <= max || atomic.CompareAndSwapInt32(&g.max, max, n) {
+			break
+		}
+	}
+
+	g.reached <- name
+
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	g.h.ServeHTTP(w, r)
+}
+
+// wait waits for the next creation request to arrive and returns the name of its upload.
+func (g *gatedHandler) wait(t *testing.T) string {
+	select {
+	case name := <-g.reached:
+		return name
+	case <-time.After(5 * time.Second):
+		t.Fatal("no upload was created")
+		return ""
+	}
+}
+
+// wrap makes the gated handler serve the requests with h.
+func (g *gatedHandler) wrap(h http.Handler) http.Handler {
+	g.h = h
+	return g
+}
+
+func newNamedUpload(name string) *Upload {
+	u := NewUploadFromBytes([]byte(name + "-1234567890"))
+	u.Metadata["filename"] = name
+	return u
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	gate := make(chan struct{})
+
+	g := newGatedHandler(map[string]chan struct{}{"a": gate, "b": gate})
+
+	client, store, closeServer := newTestClient(t, g.wrap, nil)
+	defer closeServer()
+
+	m, err := NewManager(client, 2)
+	assert.Nil(t, err)
+
+	names := []string{"a", "b", "c", "d", "e", "f"}
+
+	for _, name := range names {
+		m.Add(newNamedUpload(name), 0)
+	}
+
+	// Both workers are busy until the first uploads are released.
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{g.wait(t), g.wait(t)})
+	close(gate)
+
+	results := m.Wait()
+
+	if assert.Len(t, results, len(names)) {
+		for i, r := range results {
+			assert.Nil(t, r.Err)
+			assert.Equal(t, names[i], r.Upload.Metadata["filename"])
+			assert.NotEmpty(t, r.Url)
+
+			up, err := store.GetUpload(ctx, uploadIDFromURL(r.Url))
+			assert.Nil(t, err)
+
+			fi, err := up.GetInfo(ctx)
+			assert.Nil(t, err)
+			assert.EqualValues(t, r.Upload.Size(), fi.Offset)
+		}
+	}
+
+	assert.EqualValues(t, 2, atomic.LoadInt32(&g.max))
+
+	p := m.Progress()
+	assert.Equal(t, len(names), p.Jobs)
+	assert.Equal(t, len(names), p.Completed)
+	assert.Zero(t, p.Queued+p.Running+p.Failed)
+	assert.EqualValues(t, 6*12, p.Size)
+	assert.Equal(t, p.Size, p.Offset)
+}
+
+func TestManagerPriority(t *testing.T) {
+	blocker := make(chan struct{})
+
+	g := newGatedHandler(map[string]chan struct{}{"blocker": blocker})
+
+	client, _, closeServer := newTestClient(t, g.wrap, nil)
+	defer closeServer()
+
+	m, err := NewManager(client, 1)
+	assert.Nil(t, err)
+
+	m.Add(newNamedUpload("blocker"), 0)
+
+	// Waits the blocker to take the only worker.
+	assert.Equal(t, "blocker", g.wait(t))
+
+	m.Add(newNamedUpload("low"), -1)
+	m.Add(newNamedUpload("mid"), 0)
+	m.Add(newNamedUpload("high"), 10)
+
+	p := m.Progress()
+	assert.Equal(t, 3, p.Queued)
+	assert.Equal(t, 1, p.Running)
+
+	close(blocker)
+
+	for _, r := range m.Wait() {
+		assert.Nil(t, r.Err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	assert.Equal(t, []string{"blocker", "high", "mid", "low"}, g.created)
+}
+
+func TestManagerCancel(t *testing.T) {
+	blocker := make(chan struct{})
+
+	g := newGatedHandler(map[string]chan struct{}{"blocker": blocker})
+
+	client, _, closeServer := newTestClient(t, g.wrap, nil)
+	defer closeServer()
+	defer close(blocker)
+
+	m, err := NewManager(client, 1)
+	assert.Nil(t, err)
+
+	running := m.Add(newNamedUpload("blocker"), 0)
+
+	// Waits the blocker creation request to reach the server.
+	assert.Equal(t, "blocker", g.wait(t))
+
+	queued := m.Add(newNamedUpload("a"), 0)
+	other := m.Add(newNamedUpload("b"), 0)
+
+	queued.Cancel()
+
+	select {
+	case <-queued.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued job wasn't cancelled")
+	}
+
+	// Interrupts the blocked creation request.
+	running.Cancel()
+	running.Cancel()
+
+	results := m.Wait()
+
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, context.Canceled, results[0].Err)
+		assert.Equal(t, context.Canceled, results[1].Err)
+		assert.Nil(t, results[2].Err)
+		assert.Equal(t, other, results[2].Job)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	assert.Equal(t, []string{"blocker", "b"}, g.created)
+
+	p := m.Progress()
+	assert.Equal(t, 2, p.Failed)
+	assert.Equal(t, 1, p.Completed)
+}
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(nil, 1)
+	assert.Equal(t, ErrNilClient, err)
+
+	client, err := NewClient("http://tus.io/uploads", nil)
+	assert.Nil(t, err)
+
+	_, err = NewManager(client, 0)
+	assert.Equal(t, ErrConcurrency, err)
+}
+
+// blockingHandler blocks the given PATCH request until release is closed or the client gives up on it.
+func blockingHandler(h http.Handler, block int32, reached, release chan struct{}) http.Handler {
+	var patches int32
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" && atomic.AddInt32(&patches, 1) == block {
+			data, _ := ioutil.ReadAll(r.Body)
+			r.Body = ioutil.NopCloser(bytes.NewReader(data))
+			close(reached)
+
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newBlockingManager(t *testing.T, reached, release chan struct{}) (*Manager, func()) {
+	client, _, closeServer := newTestClient(t, func(h http.Handler) http.Handler {
+		return blockingHandler(h, 1, reached, release)
+	}, nil)
+
+	m, err := NewManager(client, 1)
+	assert.Nil(t, err)
+
+	return m, closeServer
+}
+
+func TestManagerPauseResume(t *testing.T) {
+	reached := make(chan struct{})
+	release := make(chan struct{})
+
+	m, closeServer := newBlockingManager(t, reached, release)
+	defer closeServer()
+	defer close(release)
+
+	paused := m.Add(newNamedUpload("a"), 0)
+	other := m.Add(newNamedUpload("b"), 0)
+
+	assert.Equal(t, ErrNotPaused, paused.Resume())
+
+	<-reached
+
+	// Pausing interrupts the blocked chunk and releases the only worker.
+	assert.Nil(t, paused.Pause())
+	assert.Nil(t, paused.Pause())
+
+	r := other.Wait()
+	assert.Nil(t, r.Err)
+
+	assert.True(t, paused.IsPaused())
+	assert.Equal(t, StatePaused, paused.Uploader().State())
+
+	p := m.Progress()
+	assert.Equal(t, 1, p.Paused)
+	assert.Equal(t, 1, p.Completed)
+
+	select {
+	case <-paused.Done():
+		t.Fatal("paused job was finished")
+	default:
+	}
+
+	assert.Nil(t, paused.Resume())
+
+	r = paused.Wait()
+	assert.Nil(t, r.Err)
+	assert.NotEmpty(t, r.Url)
+	assert.EqualValues(t, r.Upload.Size(), paused.Uploader().Offset())
+
+	assert.False(t, paused.IsPaused())
+	assert.Equal(t, ErrUploadFinished, paused.Pause())
+
+	p = m.Progress()
+	assert.Equal(t, 2, p.Completed)
+	assert.Equal(t, p.Size, p.Offset)
+}
+
+func TestManagerPauseQueued(t *testing.T) {
+	reached := make(chan struct{})
+	release := make(chan struct{})
+
+	m, closeServer := newBlockingManager(t, reached, release)
+	defer closeServer()
+
+	running := m.Add(newNamedUpload("a"), 0)
+	queued := m.Add(newNamedUpload("b"), 0)
+	cancelled := m.Add(newNamedUpload("c"), 0)
+
+	<-reached
+
+	assert.Nil(t, queued.Pause())
+	assert.Nil(t, cancelled.Pause())
+
+	close(release)
+
+	assert.Nil(t, running.Wait().Err)
+	assert.Equal(t, 2, m.Progress().Paused)
+
+	// A paused job can be cancelled.
+	cancelled.Cancel()
+	assert.Equal(t, context.Canceled, cancelled.Wait().Err)
+
+	assert.Nil(t, queued.Resume())
+	assert.Nil(t, queued.Wait().Err)
+}
+
+func TestManagerAbort(t *testing.T) {
+	reached := make(chan struct{})
+	release := make(chan struct{})
+
+	m, closeServer := newBlockingManager(t, reached, release)
+	defer closeServer()
+
+	j := m.Add(newNamedUpload("a"), 0)
+
+	<-reached
+
+	// The blocked chunk is finished, but not the remaining ones.
+	j.Uploader().Abort()
+	close(release)
+
+	r := j.Wait()
+	assert.Equal(t, ErrUploadAborted, r.Err)
+	assert.Equal(t, 1, m.Progress().Failed)
+}
+
+func TestManagerProgressDeferredSize(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, nil)
+	defer closeServer()
+
+	m, err := NewManager(client, 1)
+	assert.Nil(t, err)
+
+	data := []byte("1234567890")
+
+	j := m.Add(NewUploadWithDeferredLength(bytes.NewReader(data), nil, ""), 0)
+
+	// Run with -race, the size is declared while the progress is read.
+	for {
+		select {
+		case <-j.Done():
+			assert.Nil(t, j.Wait().Err)
+
+			p := m.Progress()
+			assert.EqualValues(t, len(data), p.Size)
+			assert.Equal(t, p.Size, p.Offset)
+			return
+		default:
+			assert.True(t, m.Progress().Size <= int64(len(data)))
+		}
+	}
+}
+
+func TestManagerAddFunc(t *testing.T) {
+	reached := make(chan struct{})
+	release := make(chan struct{})
+
+	m, closeServer := newBlockingManager(t, reached, release)
+	defer closeServer()
+
+	var opened int32
+
+	open := func(name string) func() (*Upload, error) {
+		return func() (*Upload, error) {
+			atomic.AddInt32(&opened, 1)
+			return newNamedUpload(name), nil
+		}
+	}
+
+	a := m.AddFunc(open("a"), 0)
+	b := m.AddFunc(open("b"), 0)
+	failed := m.AddFunc(func() (*Upload, error) {
+		return nil, os.ErrNotExist
+	}, 0)
+
+	<-reached
+
+	// Only the running job was opened.
+	assert.EqualValues(t, 1, atomic.LoadInt32(&opened))
+	assert.NotNil(t, a.Upload())
+	assert.Nil(t, b.Upload())
+	assert.EqualValues(t, a.Upload().Size(), m.Progress().Size)
+
+	close(release)
+
+	assert.Nil(t, a.Wait().Err)
+	assert.Nil(t, b.Wait().Err)
+	assert.Equal(t, os.ErrNotExist, failed.Wait().Err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&opened))
+}
@@ -0,0 +1,245 @@
+// Package memcachedstore implements tus.Store and tus.UploadStore on top of memcached.
+package memcachedstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/eventials/go-tus"
+)
+
+// maxKeyLength is the longest key accepted by memcached.
+const maxKeyLength = 250
+
+// maxRelativeExpiration is the longest expiration memcached accepts as relative,
+// longer ones must be sent as unix timestamps.
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+var (
+	ErrNoServers = errors.New("memcached servers required.")
+	ErrPrefix    = errors.New("prefix is too long or contains invalid characters.")
+)
+
+// Config configures the memcached servers and how the keys are saved.
+type Config struct {
+	// Servers are the memcached addresses, as host:port.
+	Servers []string
+	// Timeout is the socket read/write timeout.
+	Timeout time.Duration
+	// MaxIdleConns is the maximum number of idle connections kept per server.
+	MaxIdleConns int
+
+	// Prefix is prepended to every key, keeping the keys apart from other applications.
+	Prefix string
+	// Expiration is how long a key is kept after it was last set, zero keeps it
+	// until memcached evicts it.
+	// UploadStore expires the keys along with the upload instead, when the server informs it.
+	Expiration time.Duration
+}
+
+// DefaultConfig returns the configuration to connect to a local memcached.
+func DefaultConfig() Config {
+	return Config{
+		Servers:      []string{"localhost:11211"},
+		Timeout:      memcache.DefaultTimeout,
+		MaxIdleConns: memcache.DefaultMaxIdleConns,
+		Prefix:       "tus:",
+	}
+}
+
+type client struct {
+	mc     *memcache.Client
+	config Config
+}
+
+func newClient(c Config) (*client, error) {
+	if len(c.Servers) == 0 {
+		return nil, ErrNoServers
+	}
+
+	if !legalKey(c.Prefix) || len(c.Prefix) > maxKeyLength-len("sha256:")-sha256.Size*2 {
+		return nil, ErrPrefix
+	}
+
+	mc := memcache.New(c.Servers...)
+	mc.Timeout = c.Timeout
+	mc.MaxIdleConns = c.MaxIdleConns
+
+	return &client{mc: mc, config: c}, nil
+}
+
+// key returns the memcached key of the fingerprint.
+// Fingerprints too long or with characters memcached doesn't accept are hashed.
+func (c *client) key(fingerprint string) string {
+	key := c.config.Prefix + fingerprint
+
+	if legalKey(key) {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(fingerprint))
+
+	return c.config.Prefix + "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (c *client) get(fingerprint string) ([]byte, error) {
+	item, err := c.mc.Get(c.key(fingerprint))
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Value, nil
+}
+
+func (c *client) set(fingerprint string, value []byte, expiration time.Duration) error {
+	return c.mc.Set(&memcache.Item{
+		Key:        c.key(fingerprint),
+		Value:      value,
+		Expiration: expirationTime(expiration),
+	})
+}
+
+func (c *client) delete(fingerprint string) error {
+	err := c.mc.Delete(c.key(fingerprint))
+	if err == memcache.ErrCacheMiss {
+		return nil
+	}
+
+	return err
+}
+
+// MemcachedStore implements tus.Store.
+type MemcachedStore struct {
+	client *client
+}
+
+// NewMemcachedStore creates a new MemcachedStore.
+func NewMemcachedStore(c Config) (tus.Store, error) {
+	client, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemcachedStore{client: client}, nil
+}
+
+func (s *MemcachedStore) Get(fingerprint string) (string, bool) {
+	url, err := s.client.get(fingerprint)
+	return string(url), err == nil
+}
+
+func (s *MemcachedStore) Set(fingerprint, url string) {
+	s.client.set(fingerprint, []byte(url), s.client.config.Expiration)
+}
+
+func (s *MemcachedStore) Delete(fingerprint string) {
+	s.client.delete(fingerprint)
+}
+
+func (s *MemcachedStore) Close() {
+	s.client.mc.Close()
+}
+
+// MemcachedUploadStore implements tus.UploadStore, saving the records JSON encoded.
+// Memcached can't list its keys, so List returns tus.ErrListNotSupported.
+type MemcachedUploadStore struct {
+	client *client
+}
+
+// NewMemcachedUploadStore creates a new MemcachedUploadStore.
+func NewMemcachedUploadStore(c Config) (tus.UploadStore, error) {
+	client, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemcachedUploadStore{client: client}, nil
+}
+
+func (s *MemcachedUploadStore) Get(ctx context.Context, fingerprint string) (*tus.UploadRecord, error) {
+	value, err := s.client.get(fingerprint)
+	if err == memcache.ErrCacheMiss {
+		return nil, tus.ErrUploadNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var record tus.UploadRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+
+	// A hashed key may be shared by other fingerprint, even though unlikely.
+	if record.Fingerprint != fingerprint {
+		return nil, tus.ErrUploadNotFound
+	}
+
+	return &record, nil
+}
+
+func (s *MemcachedUploadStore) Set(ctx context.Context, record *tus.UploadRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	expiration := s.client.config.Expiration
+
+	if !record.ExpiresAt.IsZero() {
+		expiration = time.Until(record.ExpiresAt)
+
+		// The upload is already expired, nothing to keep.
+		if expiration < time.Second {
+			return s.Delete(ctx, record.Fingerprint)
+		}
+	}
+
+	return s.client.set(record.Fingerprint, value, expiration)
+}
+
+func (s *MemcachedUploadStore) Delete(ctx context.Context, fingerprint string) error {
+	return s.client.delete(fingerprint)
+}
+
+func (s *MemcachedUploadStore) List(ctx context.Context) ([]*tus.UploadRecord, error) {
+	return nil, tus.ErrListNotSupported
+}
+
+func (s *MemcachedUploadStore) Close() error {
+	return s.client.mc.Close()
+}
+
+// expirationTime converts the expiration to the memcached representation,
+// seconds when up to 30 days or an unix timestamp otherwise.
+func expirationTime(d time.Duration) int32 {
+	if d <= 0 {
+		return 0
+	}
+
+	if d > maxRelativeExpiration {
+		return int32(time.Now().Add(d).Unix())
+	}
+
+	// Rounds up, memcached would take zero as never expiring.
+	return int32((d + time.Second - 1) / time.Second)
+}
+
+// legalKey reports whether memcached accepts the key.
+func legalKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,385 @@
+package memcachedstore
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eventials/go-tus"
+	"github.com/eventials/go-tus/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeItem struct {
+	value      []byte
+	flags      uint32
+	expiration int32
+	expires    time.Time
+}
+
+// fakeMemcached serves the subset of the memcached text protocol used by the store.
+type fakeMemcached struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	items map[string]fakeItem
+	now   time.Time
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	m := &fakeMemcached{
+		ln:    ln,
+		items: make(map[string]fakeItem),
+		now:   time.Now(),
+	}
+
+	go m.serve()
+
+	return m
+}
+
+func (m *fakeMemcached) Addr() string {
+	return m.ln.Addr().String()
+}
+
+func (m *fakeMemcached) Close() {
+	m.ln.Close()
+}
+
+// FastForward moves the server clock, expiring the items.
+func (m *fakeMemcached) FastForward(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = m.now.Add(d)
+}
+
+func (m *fakeMemcached) item(key string) (fakeItem, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.items[key]
+
+	if ok && !it.expires.IsZero() && !m.now.Before(it.expires) {
+		delete(m.items, key)
+		return fakeItem{}, false
+	}
+
+	return it, ok
+}
+
+func (m *fakeMemcached) keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []string
+
+	for k := range m.items {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func (m *fakeMemcached) serve() {
+	for {
+		conn, err := m.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		go m.handle(conn)
+	}
+}
+
+func (m *fakeMemcached) handle(conn net.Conn) {
+	defer conn.Close()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			return
+		}
+
+		switch fields[0] {
+		case "get", "gets":
+			for _, key := range fields[1:] {
+				if it, ok := m.item(key); ok {
+					fmt.Fprintf(rw, "VALUE %s %d %d 1\r\n%s\r\n", key, it.flags, len(it.value), it.value)
+				}
+			}
+
+			rw.WriteString("END\r\n")
+		case "set":
+			var it fakeItem
+			var size int
+
+			fmt.Sscanf(strings.Join(fields[2:], " "), "%d %d %d", &it.flags, &it.expiration, &size)
+
+			it.value = make([]byte, size+2)
+
+			if _, err := io.ReadFull(rw, it.value); err != nil {
+				return
+			}
+
+			it.value = it.value[:size]
+
+			m.mu.Lock()
+
+			if it.expiration > 0 {
+				if time.Duration(it.expiration)*time.Second > maxRelativeExpiration {
+					it.expires = time.Unix(int64(it.expiration), 0)
+				} else {
+					it.expires = m.now.Add(time.Duration(it.expiration) * time.Second)
+				}
+			}
+
+			m.items[fields[1]] = it
+			m.mu.Unlock()
+
+			rw.WriteString("STORED\r\n")
+		case "delete":
+			if _, ok := m.item(fields[1]); ok {
+				m.mu.Lock()
+				delete(m.items, fields[1])
+				m.mu.Unlock()
+
+				rw.WriteString("DELETED\r\n")
+			} else {
+				rw.WriteString("NOT_FOUND\r\n")
+			}
+		default:
+			rw.WriteString("ERROR\r\n")
+		}
+
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func newTestConfig(t *testing.T) (Config, *fakeMemcached) {
+	m := newFakeMemcached(t)
+
+	c := DefaultConfig()
+	c.Servers = []string{m.Addr()}
+
+	return c, m
+}
+
+func TestMemcachedStore(t *testing.T) {
+	c, m := newTestConfig(t)
+	defer m.Close()
+
+	s, err := NewMemcachedStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("a", "http://tus.io/uploads/1")
+	s.Set("a", "http://tus.io/uploads/2")
+
+	url, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/2", url)
+	assert.Equal(t, []string{"tus:a"}, m.keys())
+
+	s.Delete("a")
+	s.Delete("a")
+
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+}
+
+func TestMemcachedStoreExpiration(t *testing.T) {
+	c, m := newTestConfig(t)
+	defer m.Close()
+
+	c.Expiration = time.Minute
+
+	s, err := NewMemcachedStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	s.Set("a", "http://tus.io/uploads/1")
+
+	it, _ := m.item("tus:a")
+	assert.EqualValues(t, 60, it.expiration)
+
+	m.FastForward(2 * time.Minute)
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+}
+
+func TestMemcachedStoreLongKeys(t *testing.T) {
+	c, m := newTestConfig(t)
+	defer m.Close()
+
+	s, err := NewMemcachedStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	long := strings.Repeat("a", 300)
+	spaced := "/home/user/my file.mp4-1024"
+
+	s.Set(long, "http://tus.io/uploads/1")
+	s.Set(long+"b", "http://tus.io/uploads/2")
+	s.Set(spaced, "http://tus.io/uploads/3")
+
+	url, ok := s.Get(long)
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/1", url)
+
+	url, ok = s.Get(long + "b")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/2", url)
+
+	url, ok = s.Get(spaced)
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/3", url)
+
+	for _, key := range m.keys() {
+		assert.True(t, strings.HasPrefix(key, "tus:sha256:"))
+		assert.True(t, len(key) <= maxKeyLength)
+	}
+}
+
+func TestMemcachedUploadStore(t *testing.T) {
+	ctx := context.Background()
+
+	c, m := newTestConfig(t)
+	defer m.Close()
+
+	s, err := NewMemcachedUploadStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+
+	record := &tus.UploadRecord{
+		Fingerprint: "a",
+		Url:         "http://tus.io/uploads/1",
+		Size:        10,
+		Offset:      4,
+		Metadata:    tus.Metadata{"filename": "file.txt"},
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	assert.Nil(t, s.Set(ctx, record))
+
+	stored, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, record, stored)
+
+	_, err = s.List(ctx)
+	assert.Equal(t, tus.ErrListNotSupported, err)
+
+	assert.Nil(t, s.Delete(ctx, "a"))
+	assert.Nil(t, s.Delete(ctx, "a"))
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+}
+
+func TestMemcachedUploadStoreExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	c, m := newTestConfig(t)
+	defer m.Close()
+
+	c.Expiration = time.Minute
+
+	s, err := NewMemcachedUploadStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	// The key expires along with the upload.
+	err = s.Set(ctx, &tus.UploadRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.Nil(t, err)
+
+	it, _ := m.item("tus:a")
+	assert.InDelta(t, 3600, it.expiration, 2)
+
+	// Expirations longer than 30 days are sent as timestamps.
+	expires := time.Now().Add(60 * 24 * time.Hour)
+
+	err = s.Set(ctx, &tus.UploadRecord{Fingerprint: "b", ExpiresAt: expires})
+	assert.Nil(t, err)
+
+	it, _ = m.item("tus:b")
+	assert.InDelta(t, expires.Unix(), it.expiration, 2)
+
+	// Falls back to the configured expiration.
+	err = s.Set(ctx, &tus.UploadRecord{Fingerprint: "c"})
+	assert.Nil(t, err)
+
+	it, _ = m.item("tus:c")
+	assert.EqualValues(t, 60, it.expiration)
+
+	// Expired uploads are removed.
+	err = s.Set(ctx, &tus.UploadRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(-time.Minute)})
+	assert.Nil(t, err)
+
+	_, ok := m.item("tus:a")
+	assert.False(t, ok)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := NewMemcachedStore(Config{})
+	assert.Equal(t, ErrNoServers, err)
+
+	c := DefaultConfig()
+	c.Prefix = "my prefix"
+
+	_, err = NewMemcachedUploadStore(c)
+	assert.Equal(t, ErrPrefix, err)
+
+	c.Prefix = strings.Repeat("a", 200)
+
+	_, err = NewMemcachedUploadStore(c)
+	assert.Equal(t, ErrPrefix, err)
+}
+
+func TestConformance(t *testing.T) {
+	c, m := newTestConfig(t)
+	defer m.Close()
+
+	var n int
+
+	storetest.TestStore(t, func(t *testing.T) tus.Store {
+		n++
+		c.Prefix = fmt.Sprintf("tus:%d:", n)
+		s, err := NewMemcachedStore(c)
+		assert.Nil(t, err)
+		return s
+	})
+
+	storetest.TestUploadStore(t, func(t *testing.T) tus.UploadStore {
+		n++
+		c.Prefix = fmt.Sprintf("tus:%d:", n)
+		s, err := NewMemcachedUploadStore(c)
+		assert.Nil(t, err)
+		return s
+	})
+}
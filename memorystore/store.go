@@ -1,36 +1,176 @@
 package memorystore
 
 import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/eventials/go-tus"
 )
 
-// MemoryStore implements an in-memory Store.
+var ErrInvalidConfig = errors.New("ttl and max entries must not be negative.")
+
+// Config bounds how long and how many entries the MemoryStore keeps.
+// Zero values disable the corresponding limit.
+type Config struct {
+	// TTL is how long an entry is kept after it was last set.
+	TTL time.Duration
+	// MaxEntries is the maximum number of entries, the least recently used are evicted.
+	MaxEntries int
+}
+
+type entry struct {
+	fingerprint string
+	url         string
+	expires     time.Time
+}
+
+// MemoryStore implements an in-memory Store, safe for concurrent use.
 type MemoryStore struct {
-	m map[string]string
+	mu      sync.Mutex
+	config  Config
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
 }
 
-// NewMemoryStore creates a new MemoryStore.
+// NewMemoryStore creates a new MemoryStore without limits.
 func NewMemoryStore() (tus.Store, error) {
+	return NewMemoryStoreWithConfig(Config{})
+}
+
+// NewMemoryStoreWithConfig creates a new MemoryStore evicting entries as configured.
+func NewMemoryStoreWithConfig(c Config) (tus.Store, error) {
+	if c.TTL < 0 || c.MaxEntries < 0 {
+		return nil, ErrInvalidConfig
+	}
+
 	return &MemoryStore{
-		make(map[string]string),
+		config:  c,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
 	}, nil
 }
 
 func (s *MemoryStore) Get(fingerprint string) (string, bool) {
-	url, ok := s.m[fingerprint]
-	return url, ok
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[fingerprint]
+
+	if !ok {
+		return "", false
+	}
+
+	e := el.Value.(*entry)
+
+	if s.expired(e) {
+		s.remove(el)
+		return "", false
+	}
+
+	s.lru.MoveToFront(el)
+
+	return e.url, true
 }
 
 func (s *MemoryStore) Set(fingerprint, url string) {
-	s.m[fingerprint] = url
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expires time.Time
+
+	if s.config.TTL > 0 {
+		expires = s.now().Add(s.config.TTL)
+	}
+
+	if el, ok := s.entries[fingerprint]; ok {
+		e := el.Value.(*entry)
+		e.url = url
+		e.expires = expires
+		s.lru.MoveToFront(el)
+		return
+	}
+
+	s.entries[fingerprint] = s.lru.PushFront(&entry{
+		fingerprint: fingerprint,
+		url:         url,
+		expires:     expires,
+	})
+
+	if s.config.MaxEntries > 0 && s.lru.Len() > s.config.MaxEntries {
+		s.evictExpired()
+	}
+
+	for s.config.MaxEntries > 0 && s.lru.Len() > s.config.MaxEntries {
+		s.remove(s.lru.Back())
+	}
 }
 
 func (s *MemoryStore) Delete(fingerprint string) {
-	delete(s.m, fingerprint)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[fingerprint]; ok {
+		s.remove(el)
+	}
 }
 
 func (s *MemoryStore) Close() {
-	for k := range s.m {
-		delete(s.m, k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[string]*list.Element)
+	s.lru.Init()
+}
+
+// Keys returns the fingerprints in the store.
+func (s *MemoryStore) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictExpired()
+
+	keys := make([]string, 0, len(s.entries))
+
+	for k := range s.entries {
+		keys = append(keys, k)
 	}
+
+	return keys
+}
+
+// Len returns the number of entries in the store, including the expired ones not evicted yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Len()
+}
+
+func (s *MemoryStore) expired(e *entry) bool {
+	return !e.expires.IsZero() && !s.now().Before(e.expires)
+}
+
+func (s *MemoryStore) evictExpired() {
+	if s.config.TTL == 0 {
+		return
+	}
+
+	for el := s.lru.Back(); el != nil; {
+		prev := el.Prev()
+
+		if s.expired(el.Value.(*entry)) {
+			s.remove(el)
+		}
+
+		el = prev
+	}
+}
+
+func (s *MemoryStore) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.entries, el.Value.(*entry).fingerprint)
 }
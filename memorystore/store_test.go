@@ -0,0 +1,160 @@
+package memorystore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eventials/go-tus"
+	"github.com/eventials/go-tus/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(t *testing.T, c Config) (*MemoryStore, *time.Time) {
+	store, err := NewMemoryStoreWithConfig(c)
+	assert.Nil(t, err)
+
+	now := time.Now()
+	s := store.(*MemoryStore)
+	s.now = func() time.Time { return now }
+
+	return s, &now
+}
+
+func TestMemoryStore(t *testing.T) {
+	s, _ := newTestStore(t, Config{})
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("a", "http://tus.io/uploads/1")
+	s.Set("a", "http://tus.io/uploads/2")
+
+	url, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/2", url)
+	assert.Equal(t, []string{"a"}, s.Keys())
+
+	s.Delete("a")
+
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("b", "http://tus.io/uploads/3")
+	s.Close()
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestMemoryStoreTTL(t *testing.T) {
+	s, now := newTestStore(t, Config{TTL: time.Minute})
+
+	s.Set("a", "http://tus.io/uploads/1")
+	*now = now.Add(30 * time.Second)
+	s.Set("b", "http://tus.io/uploads/2")
+
+	_, ok := s.Get("a")
+	assert.True(t, ok)
+
+	*now = now.Add(30 * time.Second)
+
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+
+	_, ok = s.Get("b")
+	assert.True(t, ok)
+
+	*now = now.Add(30 * time.Second)
+
+	assert.Empty(t, s.Keys())
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestMemoryStoreMaxEntries(t *testing.T) {
+	s, _ := newTestStore(t, Config{MaxEntries: 2})
+
+	s.Set("a", "http://tus.io/uploads/1")
+	s.Set("b", "http://tus.io/uploads/2")
+
+	// a becomes the most recently used.
+	_, ok := s.Get("a")
+	assert.True(t, ok)
+
+	s.Set("c", "http://tus.io/uploads/3")
+	assert.Equal(t, 2, s.Len())
+
+	_, ok = s.Get("b")
+	assert.False(t, ok)
+
+	_, ok = s.Get("a")
+	assert.True(t, ok)
+
+	_, ok = s.Get("c")
+	assert.True(t, ok)
+}
+
+func TestMemoryStoreMaxEntriesEvictsExpiredFirst(t *testing.T) {
+	s, now := newTestStore(t, Config{TTL: time.Minute, MaxEntries: 2})
+
+	s.Set("a", "http://tus.io/uploads/1")
+	*now = now.Add(45 * time.Second)
+	s.Set("b", "http://tus.io/uploads/2")
+
+	_, ok := s.Get("a")
+	assert.True(t, ok)
+
+	*now = now.Add(30 * time.Second)
+	s.Set("c", "http://tus.io/uploads/3")
+
+	_, ok = s.Get("b")
+	assert.True(t, ok)
+
+	_, ok = s.Get("c")
+	assert.True(t, ok)
+}
+
+func TestMemoryStoreInvalidConfig(t *testing.T) {
+	_, err := NewMemoryStoreWithConfig(Config{TTL: -time.Second})
+	assert.Equal(t, ErrInvalidConfig, err)
+
+	_, err = NewMemoryStoreWithConfig(Config{MaxEntries: -1})
+	assert.Equal(t, ErrInvalidConfig, err)
+}
+
+func TestMemoryStoreConcurrent(t *testing.T) {
+	store, err := NewMemoryStoreWithConfig(Config{TTL: time.Minute, MaxEntries: 50})
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 500; j++ {
+				key := fmt.Sprintf("%d-%d", i, j%100)
+				store.Set(key, key)
+				store.Get(key)
+				store.(*MemoryStore).Keys()
+
+				if j%10 == 0 {
+					store.Delete(key)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.True(t, store.(*MemoryStore).Len() <= 50)
+}
+
+func TestConformance(t *testing.T) {
+	storetest.TestStore(t, func(t *testing.T) tus.Store {
+		s, err := NewMemoryStoreWithConfig(Config{TTL: time.Hour})
+		assert.Nil(t, err)
+		return s
+	})
+}
@@ -0,0 +1,351 @@
+package tus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParallelUploader uploads an Upload as several partial uploads sent concurrently,
+// which are concatenated by the server once all of them are finished.
+// It requires the server to support the Concatenation extension.
+type ParallelUploader struct {
+	client    *Client
+	upload    *Upload
+	uploaders []*Uploader
+	createdAt time.Time
+
+	// Guards url, set once the partial uploads are concatenated.
+	mu  sync.Mutex
+	url string
+}
+
+// CreateParallelUpload splits the upload into parts partial uploads and creates them in the server.
+// If Resume is enabled the partial uploads already created are resumed instead.
+func (c *Client) CreateParallelUpload(u *Upload, parts int) (*ParallelUploader, error) {
+	return c.CreateParallelUploadWithContext(context.Background(), u, parts)
+}
+
+// CreateParallelUploadWithContext creates or resumes the partial uploads using the context for all requests.
+func (c *Client) CreateParallelUploadWithContext(ctx context.Context, u *Upload, parts int) (*ParallelUploader, error) {
+	if u == nil {
+		return nil, ErrNilUpload
+	}
+
+	if parts < 1 {
+		return nil, ErrPartsCount
+	}
+
+	if u.sizeIsDeferred {
+		return nil, ErrSizeDeferred
+	}
+
+	if !u.seekable() {
+		return nil, ErrStreamNotSeekable
+	}
+
+	if err := c.fingerprint(u); err != nil {
+		return nil, err
+	}
+
+	if c.Config.Resume && len(u.Fingerprint) == 0 {
+		return nil, ErrFingerprintNotSet
+	}
+
+	if err := c.requireExtension(ctx, "concatenation", ErrConcatenationNotSupported); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkUploadSupport(ctx, u); err != nil {
+		return nil, err
+	}
+
+	p := &ParallelUploader{
+		client:    c,
+		upload:    u,
+		createdAt: time.Now(),
+	}
+
+	// The final upload may already exist from a previous run.
+	if c.Config.Resume {
+		uploader, err := c.resumeStoredUpload(ctx, u)
+
+		if err == nil && uploader.Offset() >= u.size {
+			p.url = uploader.Url()
+			u.updateProgress(uploader.Offset())
+			return p, nil
+		} else if err != nil && err != ErrUploadNotFound {
+			return nil, err
+		}
+
+		// Keep the creation time of the record saved by the previous run.
+		record, err := c.loadRecord(ctx, u.Fingerprint)
+
+		if err == nil && !record.CreatedAt.IsZero() {
+			p.createdAt = record.CreatedAt
+		} else if err != nil && err != ErrUploadNotFound {
+			return nil, err
+		}
+	}
+
+	if int64(parts) > u.size && u.size > 0 {
+		parts = int(u.size)
+	}
+
+	partSize := u.size / int64(parts)
+
+	for i := 0; i < parts; i++ {
+		offset := int64(i) * partSize
+		size := partSize
+
+		if i == parts-1 {
+			size = u.size - offset
+		}
+
+		var fingerprint string
+
+		if len(u.Fingerprint) > 0 {
+			fingerprint = partialFingerprint(u.Fingerprint, i, parts)
+		}
+
+		uploader, err := c.createOrResumePartialUpload(ctx, u.partial(offset, size, fingerprint))
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.uploaders = append(p.uploaders, uploader)
+	}
+
+	if c.Config.Resume {
+		if err := c.saveRecord(ctx, p.record()); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (c *Client) createOrResumePartialUpload(ctx context.Context, u *Upload) (*Uploader, error) {
+	if c.Config.Resume {
+		uploader, err := c.resumeStoredUpload(ctx, u)
+
+		if err == nil {
+			u.updateProgress(uploader.Offset())
+			return uploader, nil
+		} else if err != ErrUploadNotFound {
+			return nil, err
+		}
+	}
+
+	header := make(http.Header)
+	header.Set("Upload-Length", strconv.FormatInt(u.size, 10))
+	header.Set("Upload-Concat", "partial")
+
+	url, _, expires, err := c.createUpload(ctx, header, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := NewUploader(c, url, u, 0)
+	uploader.expires = expires
+
+	if c.Config.Resume {
+		uploader.record = &UploadRecord{
+			Fingerprint: u.Fingerprint,
+			Url:         url,
+			CreatedAt:   time.Now(),
+		}
+
+		if err := uploader.saveRecord(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return uploader, nil
+}
+
+// record returns the record of the final upload, listing the partial uploads.
+func (p *ParallelUploader) record() *UploadRecord {
+	r := &UploadRecord{
+		Fingerprint: p.upload.Fingerprint,
+		Url:         p.Url(),
+		Size:        p.upload.size,
+		Offset:      p.Offset(),
+		Metadata:    p.upload.Metadata,
+		CreatedAt:   p.createdAt,
+	}
+
+	for _, u := range p.uploaders {
+		r.PartialUrls = append(r.PartialUrls, u.Url())
+	}
+
+	return r
+}
+
+// partialFingerprint returns the fingerprint used to store the url of a partial upload.
+func partialFingerprint(fingerprint string, part, parts int) string {
+	return fmt.Sprintf("%s.part-%d-of-%d", fingerprint, part+1, parts)
+}
+
+// Uploaders returns the uploaders of each partial upload.
+func (p *ParallelUploader) Uploaders() []*Uploader {
+	return p.uploaders
+}
+
+// Abort aborts the upload process of all partial uploads.
+// It doens't abort the current chuncks, only the remaining.
+func (p *ParallelUploader) Abort() {
+	for _, u := range p.uploaders {
+		u.Abort()
+	}
+}
+
+// IsAborted returns true if the upload was aborted.
+func (p *ParallelUploader) IsAborted() bool {
+	for _, u := range p.uploaders {
+		if u.IsAborted() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Url returns the url of the final upload.
+// It is empty until all partial uploads are finished and concatenated.
+func (p *ParallelUploader) Url() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.url
+}
+
+// Offset returns the amount of bytes uploaded by all partial uploads.
+func (p *ParallelUploader) Offset() int64 {
+	if len(p.Url()) > 0 {
+		return p.upload.size
+	}
+
+	var offset int64
+
+	for _, u := range p.uploaders {
+		offset += u.Offset()
+	}
+
+	return offset
+}
+
+// Upload uploads all partial uploads concurrently and then concatenates them in the server.
+// If any partial upload fails the remaining ones are stopped and the first error is returned,
+// Upload can be called again to continue them. It returns ErrUploadAborted if the upload was aborted.
+func (p *ParallelUploader) Upload() error {
+	return p.UploadWithContext(context.Background())
+}
+
+// UploadWithContext uploads all partial uploads concurrently using the context for all requests.
+func (p *ParallelUploader) UploadWithContext(ctx context.Context) error {
+	if len(p.Url()) > 0 {
+		return nil
+	}
+
+	if p.IsAborted() {
+		return ErrUploadAborted
+	}
+
+	// Cancelled when a partial upload fails, stopping the others without aborting them.
+	partsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var uploadErr error
+
+	for _, u := range p.uploaders {
+		wg.Add(1)
+
+		go func(u *Uploader) {
+			defer wg.Done()
+
+			if err := p.uploadPart(partsCtx, u); err != nil {
+				once.Do(func() {
+					uploadErr = err
+					cancel()
+				})
+			}
+		}(u)
+	}
+
+	wg.Wait()
+
+	if uploadErr != nil {
+		return uploadErr
+	}
+
+	if p.IsAborted() {
+		return ErrUploadAborted
+	}
+
+	return p.concatenate(ctx)
+}
+
+// uploadPart uploads a partial upload. A partial upload stopped by a previous failure
+// continues from the offset the server has, since its last chunck may have been partially written.
+func (p *ParallelUploader) uploadPart(ctx context.Context, u *Uploader) error {
+	if u.State() == StateFailed {
+		if err := u.syncOffset(ctx); err != nil {
+			return err
+		}
+	}
+
+	return u.UploadWithContext(ctx)
+}
+
+// concatenate creates the final upload from the finished partial uploads.
+func (p *ParallelUploader) concatenate(ctx context.Context) error {
+	c := p.client
+
+	urls := make([]string, len(p.uploaders))
+
+	for i, u := range p.uploaders {
+		urls[i] = u.Url()
+	}
+
+	header := make(http.Header)
+	header.Set("Upload-Metadata", p.upload.EncodedMetadata())
+	header.Set("Upload-Concat", "final;"+strings.Join(urls, " "))
+
+	url, _, expires, err := c.createUpload(ctx, header, nil)
+
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.url = url
+	p.mu.Unlock()
+
+	p.upload.updateProgress(p.upload.size)
+
+	if c.Config.Resume {
+		r := p.record()
+		r.ExpiresAt = expires
+
+		if err := c.saveRecord(ctx, r); err != nil {
+			return err
+		}
+
+		for _, u := range p.uploaders {
+			if err := c.deleteRecord(ctx, u.upload.Fingerprint); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
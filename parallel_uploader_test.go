@@ -0,0 +1,70 @@
+package tus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelUploadAfterFailure(t *testing.T) {
+	ctx := context.Background()
+
+	// A partial upload stopped by the failure may still be written by the server after
+	// its offset is fetched again, so the offset mismatch is retried.
+	policy := &RetryPolicy{
+		MaxAttempts:     3,
+		InitialBackoff:  time.Millisecond,
+		Multiplier:      1,
+		RetryableStatus: []int{409},
+	}
+
+	client, store, closeServer := newTestClient(t, flaky(map[int32]int{3: 400}), withRetryPolicy(policy))
+	defer closeServer()
+
+	data := make([]byte, 64)
+
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	uploader, err := client.CreateParallelUpload(NewUploadFromBytes(data), 2)
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Equal(t, 400, err.(ClientError).Code)
+	assert.Empty(t, uploader.Url())
+	assert.False(t, uploader.IsAborted())
+
+	// The partial uploads stopped by the failure continue.
+	err = uploader.Upload()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, uploader.Url())
+	assert.EqualValues(t, len(data), uploader.Offset())
+
+	up, err := store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	assert.Nil(t, err)
+
+	fi, err := up.GetInfo(ctx)
+	assert.Nil(t, err)
+	assert.True(t, fi.IsFinal)
+	assert.EqualValues(t, len(data), fi.Offset)
+}
+
+func TestParallelUploadAborted(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, nil)
+	defer closeServer()
+
+	uploader, err := client.CreateParallelUpload(NewUploadFromBytes(make([]byte, 64)), 2)
+	assert.Nil(t, err)
+
+	uploader.Abort()
+
+	err = uploader.Upload()
+	assert.Equal(t, ErrUploadAborted, err)
+	assert.Empty(t, uploader.Url())
+
+	err = uploader.Upload()
+	assert.Equal(t, ErrUploadAborted, err)
+}
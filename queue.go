@@ -0,0 +1,413 @@
+package tus
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// QueueState is the state of an upload in a Queue.
+type QueueState string
+
+const (
+	// QueuePending is the state of an upload not finished yet, it's resumed when the queue is opened.
+	QueuePending QueueState = "pending"
+	// QueueCompleted is the state of an upload whose file was entirely uploaded.
+	QueueCompleted QueueState = "completed"
+	// QueueFailed is the state of an upload which failed, it's retried when the queue is opened.
+	QueueFailed QueueState = "failed"
+	// QueueCancelled is the state of an upload cancelled with Queue.Cancel.
+	QueueCancelled QueueState = "cancelled"
+)
+
+// QueueEntry is an upload journaled by a Queue.
+type QueueEntry struct {
+	ID       uint64     `json:"id"`
+	Path     string     `json:"path"`
+	Metadata Metadata   `json:"metadata,omitempty"`
+	Priority int        `json:"priority"`
+	State    QueueState `json:"state"`
+	// Url is the upload url, empty until the upload is created.
+	Url string `json:"url,omitempty"`
+	// Err is the error of a failed upload.
+	Err     string    `json:"err,omitempty"`
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// queueJournal is the content of the journal file.
+type queueJournal struct {
+	NextID  uint64        `json:"nextId"`
+	Entries []*QueueEntry `json:"entries"`
+}
+
+// Queue uploads files through a Manager, journaling the queue to a file so the
+// uploads not finished are added again, and resumed from their offset in the server,
+// when the queue is opened after the process restarts.
+// Each file is opened only while its upload runs.
+// The Client of the Manager must have resuming enabled.
+type Queue struct {
+	path    string
+	manager *Manager
+
+	mu      sync.Mutex
+	journal queueJournal
+	jobs    map[uint64]*Job
+	closed  bool
+	err     error
+	wg      sync.WaitGroup
+}
+
+// OpenQueue opens the queue journaled at path, creating it if it doesn't exist,
+// and adds its pending and failed uploads to the manager.
+func OpenQueue(path string, manager *Manager) (*Queue, error) {
+	if manager == nil {
+		return nil, ErrNilManager
+	}
+
+	if !manager.client.Config.Resume {
+		return nil, ErrResumeNotEnabled
+	}
+
+	q := &Queue{
+		path:    path,
+		manager: manager,
+		jobs:    make(map[uint64]*Job),
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err == nil {
+		if err := json.Unmarshal(data, &q.journal); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range q.journal.Entries {
+		if e.State != QueuePending && e.State != QueueFailed {
+			continue
+		}
+
+		// A failure may have been temporary, such as a network error.
+		e.State = QueuePending
+		e.Err = ""
+
+		q.start(e)
+	}
+
+	if err := q.save(); err != nil {
+		q.closed = true
+		q.cancelJobs()
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Add journals the upload of the file at path and adds it to the manager.
+// The metadata is added to the metadata of the file.
+func (q *Queue) Add(path string, metadata Metadata, priority int) (QueueEntry, error) {
+	path, err := filepath.Abs(path)
+
+	if err != nil {
+		return QueueEntry{}, err
+	}
+
+	// The file is only opened once its upload is started.
+	if _, err := os.Stat(path); err != nil {
+		return QueueEntry{}, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return QueueEntry{}, ErrQueueClosed
+	}
+
+	q.journal.NextID++
+
+	e := &QueueEntry{
+		ID:       q.journal.NextID,
+		Path:     path,
+		Metadata: metadata,
+		Priority: priority,
+		State:    QueuePending,
+		AddedAt:  time.Now(),
+	}
+
+	q.journal.Entries = append(q.journal.Entries, e)
+
+	// The entry is journaled before the upload starts.
+	if err := q.save(); err != nil {
+		q.journal.Entries = q.journal.Entries[:len(q.journal.Entries)-1]
+		return QueueEntry{}, err
+	}
+
+	q.start(e)
+
+	return *e, nil
+}
+
+// Entries returns all journaled uploads.
+func (q *Queue) Entries() []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]QueueEntry, len(q.journal.Entries))
+
+	for i, e := range q.journal.Entries {
+		entries[i] = *e
+	}
+
+	return entries
+}
+
+// Cancel cancels the upload, which isn't resumed anymore.
+func (q *Queue) Cancel(id uint64) error {
+	q.mu.Lock()
+
+	e := q.entry(id)
+
+	if e == nil {
+		q.mu.Unlock()
+		return ErrEntryNotFound
+	}
+
+	if e.State != QueuePending {
+		q.mu.Unlock()
+		return nil
+	}
+
+	e.State = QueueCancelled
+	j := q.jobs[id]
+
+	err := q.save()
+	q.mu.Unlock()
+
+	if j != nil {
+		j.Cancel()
+	}
+
+	return err
+}
+
+// Remove removes a finished upload from the journal.
+func (q *Queue) Remove(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.journal.Entries {
+		if e.ID != id {
+			continue
+		}
+
+		if e.State == QueuePending {
+			return ErrEntryPending
+		}
+
+		q.journal.Entries = append(q.journal.Entries[:i], q.journal.Entries[i+1:]...)
+
+		return q.save()
+	}
+
+	return ErrEntryNotFound
+}
+
+// Wait waits for the uploads added so far to finish.
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// Close stops the uploads, keeping the unfinished ones pending so they're resumed
+// when the queue is opened again. It returns the first error journaling a finished upload.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+
+	q.closed = true
+	q.cancelJobs()
+	q.mu.Unlock()
+
+	q.wg.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.save(); err != nil {
+		return err
+	}
+
+	return q.err
+}
+
+// open opens the file of the entry and creates its upload.
+func (e *QueueEntry) open() (*Upload, *os.File, error) {
+	f, err := os.Open(e.Path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := NewUploadFromFile(f)
+
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	for k, v := range e.Metadata {
+		u.Metadata[k] = v
+	}
+
+	return u, f, nil
+}
+
+// start adds the upload of the entry to the manager. The file is opened when the job
+// is started and closed once it's finished. Must be called holding mu.
+func (q *Queue) start(e *QueueEntry) {
+	// Set by the job before it's finished, so it's read by finish after Wait.
+	var f *os.File
+
+	j := q.manager.AddFunc(func() (*Upload, error) {
+		u, file, err := e.open()
+		f = file
+		return u, err
+	}, e.Priority)
+
+	q.jobs[e.ID] = j
+	q.wg.Add(1)
+
+	go q.finish(e.ID, j, func() {
+		if f != nil {
+			f.Close()
+		}
+	})
+}
+
+// finish journals the result of the upload of the entry once its job is finished.
+func (q *Queue) finish(id uint64, j *Job, closeFile func()) {
+	defer q.wg.Done()
+
+	r := j.Wait()
+	closeFile()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.jobs, id)
+
+	e := q.entry(id)
+
+	if e == nil || e.State != QueuePending {
+		return
+	}
+
+	if len(r.Url) > 0 {
+		e.Url = r.Url
+	}
+
+	switch {
+	case r.Err == nil:
+		e.State = QueueCompleted
+		e.Err = ""
+	case q.closed:
+		// Interrupted by Close, it's resumed when the queue is opened again.
+		return
+	default:
+		e.State = QueueFailed
+		e.Err = r.Err.Error()
+	}
+
+	if err := q.save(); err != nil && q.err == nil {
+		q.err = err
+	}
+}
+
+// cancelJobs cancels the running uploads. Must be called holding mu.
+func (q *Queue) cancelJobs() {
+	for _, j := range q.jobs {
+		j.Cancel()
+	}
+}
+
+// entry returns the entry with the id. Must be called holding mu.
+func (q *Queue) entry(id uint64) *QueueEntry {
+	for _, e := range q.journal.Entries {
+		if e.ID == id {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// save writes the journal to a temporary file and renames it over the journal,
+// so a crash never leaves a partially written journal. Must be called holding mu.
+func (q *Queue) save() error {
+	data, err := json.MarshalIndent(&q.journal, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(q.path)
+
+	f, err := ioutil.TempFile(dir, filepath.Base(q.path)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, q.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir flushes the directory entries, persisting a rename.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Directories can't be synced on some platforms, such as Windows.
+	d.Sync()
+
+	return nil
+}
@@ -0,0 +1,315 @@
+package tus
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tus/tusd/pkg/filestore"
+)
+
+// countingHandler counts the uploads created.
+func countingHandler(h http.Handler, created *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			atomic.AddInt32(created, 1)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func newQueueTestManager(t *testing.T, url string, store Store) *Manager {
+	cfg := DefaultConfig()
+	cfg.ChunkSize = 4
+	cfg.Resume = true
+	cfg.Store = store
+
+	client, err := NewClient(url, cfg)
+	assert.Nil(t, err)
+
+	m, err := NewManager(client, 2)
+	assert.Nil(t, err)
+
+	return m
+}
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var created int32
+
+	ts := httptest.NewServer(countingHandler(newTusdHandler(filestore.FileStore{Path: os.TempDir()}), &created))
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/uploads/", ts.URL)
+	store := NewMockStore()
+	journal := filepath.Join(dir, "queue.json")
+
+	q, err := OpenQueue(journal, newQueueTestManager(t, url, store))
+	assert.Nil(t, err)
+
+	writeTempFile(t, dir, "a.txt", []byte("1234567890")).Close()
+	writeTempFile(t, dir, "b.txt", []byte("abcdefghij")).Close()
+
+	a, err := q.Add(filepath.Join(dir, "a.txt"), Metadata{"owner": "me"}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, QueuePending, a.State)
+
+	b, err := q.Add(filepath.Join(dir, "b.txt"), nil, 1)
+	assert.Nil(t, err)
+	assert.NotEqual(t, a.ID, b.ID)
+
+	_, err = q.Add(filepath.Join(dir, "missing.txt"), nil, 0)
+	assert.True(t, os.IsNotExist(err))
+
+	writeTempFile(t, dir, "c.txt", []byte("1234567890")).Close()
+
+	c, err := q.Add(filepath.Join(dir, "c.txt"), nil, 0)
+	assert.Nil(t, err)
+
+	q.Wait()
+
+	entries := q.Entries()
+
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, QueueCompleted, entries[0].State)
+		assert.Equal(t, "me", entries[0].Metadata["owner"])
+		assert.NotEmpty(t, entries[0].Url)
+		assert.Equal(t, QueueCompleted, entries[1].State)
+		assert.Equal(t, 1, entries[1].Priority)
+		assert.Equal(t, c.ID, entries[2].ID)
+	}
+
+	assert.Nil(t, q.Remove(c.ID))
+	assert.Equal(t, ErrEntryNotFound, q.Remove(c.ID))
+	assert.Nil(t, q.Close())
+
+	_, err = q.Add(filepath.Join(dir, "a.txt"), nil, 0)
+	assert.Equal(t, ErrQueueClosed, err)
+
+	// Finished uploads aren't uploaded again.
+	q, err = OpenQueue(journal, newQueueTestManager(t, url, store))
+	assert.Nil(t, err)
+
+	q.Wait()
+	assert.Len(t, q.Entries(), 2)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&created))
+	assert.Nil(t, q.Close())
+}
+
+func TestQueueResumesAfterRestart(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	fs := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	var created int32
+
+	stalled := make(chan struct{})
+
+	ts := httptest.NewServer(countingHandler(stallingHandler(newTusdHandler(fs), 1, stalled), &created))
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/uploads/", ts.URL)
+	store := NewMockStore()
+	journal := filepath.Join(dir, "queue.json")
+
+	q, err := OpenQueue(journal, newQueueTestManager(t, url, store))
+	assert.Nil(t, err)
+
+	writeTempFile(t, dir, "a.txt", []byte("1234567890")).Close()
+
+	entry, err := q.Add(filepath.Join(dir, "a.txt"), nil, 0)
+	assert.Nil(t, err)
+
+	// The first chunk is sent with the creation request and the second one stalls.
+	select {
+	case <-stalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload wasn't started")
+	}
+
+	// Interrupted uploads are kept pending.
+	assert.Nil(t, q.Close())
+
+	entries := q.Entries()
+
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, QueuePending, entries[0].State)
+	}
+
+	// The upload is resumed when the queue is opened again.
+	q, err = OpenQueue(journal, newQueueTestManager(t, url, store))
+	assert.Nil(t, err)
+
+	q.Wait()
+
+	entries = q.Entries()
+
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, entry.ID, entries[0].ID)
+		assert.Equal(t, QueueCompleted, entries[0].State)
+
+		up, err := fs.GetUpload(ctx, uploadIDFromURL(entries[0].Url))
+		assert.Nil(t, err)
+
+		fi, err := up.GetInfo(ctx)
+		assert.Nil(t, err)
+		assert.EqualValues(t, 10, fi.Offset)
+	}
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&created))
+	assert.Nil(t, q.Close())
+}
+
+func TestQueueCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	stalled := make(chan struct{})
+
+	ts := httptest.NewServer(stallingHandler(newTusdHandler(filestore.FileStore{Path: os.TempDir()}), 1, stalled))
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/uploads/", ts.URL)
+	journal := filepath.Join(dir, "queue.json")
+
+	q, err := OpenQueue(journal, newQueueTestManager(t, url, NewMockStore()))
+	assert.Nil(t, err)
+
+	writeTempFile(t, dir, "a.txt", []byte("1234567890")).Close()
+
+	entry, err := q.Add(filepath.Join(dir, "a.txt"), nil, 0)
+	assert.Nil(t, err)
+
+	<-stalled
+
+	assert.Equal(t, ErrEntryPending, q.Remove(entry.ID))
+	assert.Nil(t, q.Cancel(entry.ID))
+	assert.Equal(t, ErrEntryNotFound, q.Cancel(entry.ID+1))
+
+	q.Wait()
+
+	assert.Equal(t, QueueCancelled, q.Entries()[0].State)
+	assert.Nil(t, q.Close())
+
+	// Cancelled uploads aren't resumed.
+	q, err = OpenQueue(journal, newQueueTestManager(t, url, NewMockStore()))
+	assert.Nil(t, err)
+	assert.Equal(t, QueueCancelled, q.Entries()[0].State)
+	assert.Nil(t, q.Close())
+}
+
+func TestQueueMissingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The file was removed while the queue was closed.
+	journal := writeTempFile(t, dir, "queue.json", []byte(`{
+		"nextId": 1,
+		"entries": [{"id": 1, "path": "`+filepath.Join(dir, "missing.txt")+`", "state": "pending"}]
+	}`))
+	journal.Close()
+
+	q, err := OpenQueue(journal.Name(), newQueueTestManager(t, "http://tus.io/uploads", NewMockStore()))
+	assert.Nil(t, err)
+
+	// The file is opened once the upload is started.
+	q.Wait()
+
+	entries := q.Entries()
+
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, QueueFailed, entries[0].State)
+		assert.NotEmpty(t, entries[0].Err)
+	}
+
+	data, err := ioutil.ReadFile(journal.Name())
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"state": "failed"`)
+
+	assert.Nil(t, q.Close())
+}
+
+func TestQueueRetriesFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The first chunk is sent with the creation request and the second one fails.
+	ts := httptest.NewServer(flakyHandler(newTusdHandler(filestore.FileStore{Path: os.TempDir()}), map[int32]int{1: 500}))
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/uploads/", ts.URL)
+	store := NewMockStore()
+	journal := filepath.Join(dir, "queue.json")
+
+	q, err := OpenQueue(journal, newQueueTestManager(t, url, store))
+	assert.Nil(t, err)
+
+	writeTempFile(t, dir, "a.txt", []byte("1234567890")).Close()
+
+	_, err = q.Add(filepath.Join(dir, "a.txt"), nil, 0)
+	assert.Nil(t, err)
+
+	q.Wait()
+
+	assert.Equal(t, QueueFailed, q.Entries()[0].State)
+	assert.Nil(t, q.Close())
+
+	// Failed uploads are retried when the queue is opened again.
+	q, err = OpenQueue(journal, newQueueTestManager(t, url, store))
+	assert.Nil(t, err)
+
+	q.Wait()
+
+	entries := q.Entries()
+
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, QueueCompleted, entries[0].State)
+		assert.Empty(t, entries[0].Err)
+	}
+
+	assert.Nil(t, q.Close())
+}
+
+func TestOpenQueueErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = OpenQueue(filepath.Join(dir, "queue.json"), nil)
+	assert.Equal(t, ErrNilManager, err)
+
+	client, err := NewClient("http://tus.io/uploads", nil)
+	assert.Nil(t, err)
+
+	m, err := NewManager(client, 1)
+	assert.Nil(t, err)
+
+	_, err = OpenQueue(filepath.Join(dir, "queue.json"), m)
+	assert.Equal(t, ErrResumeNotEnabled, err)
+
+	writeTempFile(t, dir, "queue.json", []byte("{")).Close()
+
+	_, err = OpenQueue(filepath.Join(dir, "queue.json"), newQueueTestManager(t, "http://tus.io/uploads", NewMockStore()))
+	assert.NotNil(t, err)
+}
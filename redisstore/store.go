@@ -0,0 +1,300 @@
+// Package redisstore implements tus.Store and tus.UploadStore on top of Redis,
+// allowing several replicas to resume the uploads started by each other.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/eventials/go-tus"
+	"github.com/gomodule/redigo/redis"
+)
+
+var ErrNilPool = errors.New("pool or address required.")
+
+// Config configures the connection to Redis and how the keys are saved.
+type Config struct {
+	// Addr is the Redis address, as host:port. Ignored if Pool is set.
+	Addr     string
+	Password string
+	DB       int
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MaxIdle is the maximum number of idle connections kept by the pool.
+	MaxIdle int
+
+	// Pool is used instead of dialing Addr, when set.
+	Pool *redis.Pool
+
+	// Prefix is prepended to every fingerprint, keeping the keys apart from other applications.
+	Prefix string
+	// TTL is how long a key is kept after it was last set, zero keeps it forever.
+	// UploadStore expires the keys along with the upload instead, when the server informs it.
+	TTL time.Duration
+}
+
+// DefaultConfig returns the configuration to connect to a local Redis.
+func DefaultConfig() Config {
+	return Config{
+		Addr:         "localhost:6379",
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		MaxIdle:      4,
+		Prefix:       "tus:",
+	}
+}
+
+// newPool returns the configured pool or a new pool dialing the configured address.
+func newPool(c Config) (*redis.Pool, error) {
+	if c.Pool != nil {
+		return c.Pool, nil
+	}
+
+	if len(c.Addr) == 0 {
+		return nil, ErrNilPool
+	}
+
+	return &redis.Pool{
+		MaxIdle:     c.MaxIdle,
+		IdleTimeout: 5 * time.Minute,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", c.Addr,
+				redis.DialPassword(c.Password),
+				redis.DialDatabase(c.DB),
+				redis.DialConnectTimeout(c.DialTimeout),
+				redis.DialReadTimeout(c.ReadTimeout),
+				redis.DialWriteTimeout(c.WriteTimeout),
+			)
+		},
+	}, nil
+}
+
+// RedisStore implements tus.Store.
+type RedisStore struct {
+	pool   *redis.Pool
+	config Config
+}
+
+// NewRedisStore creates a new RedisStore.
+func NewRedisStore(c Config) (tus.Store, error) {
+	pool, err := newPool(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisStore{pool: pool, config: c}, nil
+}
+
+func (s *RedisStore) Get(fingerprint string) (string, bool) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	url, err := redis.String(conn.Do("GET", s.config.Prefix+fingerprint))
+	return url, err == nil
+}
+
+func (s *RedisStore) Set(fingerprint, url string) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	set(conn, s.config.Prefix+fingerprint, url, s.config.TTL)
+}
+
+func (s *RedisStore) Delete(fingerprint string) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	conn.Do("DEL", s.config.Prefix+fingerprint)
+}
+
+func (s *RedisStore) Close() {
+	s.pool.Close()
+}
+
+// Keys returns the fingerprints in the store.
+func (s *RedisStore) Keys() []string {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	keys, _ := scan(conn, s.config.Prefix)
+
+	for i, k := range keys {
+		keys[i] = k[len(s.config.Prefix):]
+	}
+
+	return keys
+}
+
+// RedisUploadStore implements tus.UploadStore, saving the records JSON encoded.
+type RedisUploadStore struct {
+	pool   *redis.Pool
+	config Config
+}
+
+// NewRedisUploadStore creates a new RedisUploadStore.
+func NewRedisUploadStore(c Config) (tus.UploadStore, error) {
+	pool, err := newPool(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisUploadStore{pool: pool, config: c}, nil
+}
+
+func (s *RedisUploadStore) Get(ctx context.Context, fingerprint string) (*tus.UploadRecord, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	value, err := redis.Bytes(conn.Do("GET", s.config.Prefix+fingerprint))
+	if err == redis.ErrNil {
+		return nil, tus.ErrUploadNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var record tus.UploadRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (s *RedisUploadStore) Set(ctx context.Context, record *tus.UploadRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	ttl := s.config.TTL
+
+	if !record.ExpiresAt.IsZero() {
+		ttl = time.Until(record.ExpiresAt)
+
+		// The upload is already expired, nothing to keep.
+		if ttl < time.Millisecond {
+			return s.Delete(ctx, record.Fingerprint)
+		}
+	}
+
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return set(conn, s.config.Prefix+record.Fingerprint, string(value), ttl)
+}
+
+func (s *RedisUploadStore) Delete(ctx context.Context, fingerprint string) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Do("DEL", s.config.Prefix+fingerprint)
+	return err
+}
+
+func (s *RedisUploadStore) List(ctx context.Context) ([]*tus.UploadRecord, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	keys, err := scan(conn, s.config.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*tus.UploadRecord
+
+	for _, key := range keys {
+		value, err := redis.Bytes(conn.Do("GET", key))
+		if err == redis.ErrNil {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var record tus.UploadRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return nil, err
+		}
+
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+func (s *RedisUploadStore) Close() error {
+	return s.pool.Close()
+}
+
+func set(conn redis.Conn, key, value string, ttl time.Duration) error {
+	var err error
+
+	if ttl > 0 {
+		_, err = conn.Do("SET", key, value, "PX", int64(ttl/time.Millisecond))
+	} else {
+		_, err = conn.Do("SET", key, value)
+	}
+
+	return err
+}
+
+// scan returns all keys starting with prefix.
+func scan(conn redis.Conn, prefix string) ([]string, error) {
+	var keys []string
+
+	cursor := 0
+
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", escapePattern(prefix)+"*", "COUNT", 100))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := redis.Scan(values, &cursor, new([]string)); err != nil {
+			return nil, err
+		}
+
+		page, err := redis.Strings(values[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, page...)
+
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// escapePattern escapes the glob characters of a key used in a pattern.
+func escapePattern(s string) string {
+	escaped := make([]byte, 0, len(s))
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			escaped = append(escaped, '\\')
+		}
+
+		escaped = append(escaped, s[i])
+	}
+
+	return string(escaped)
+}
@@ -0,0 +1,234 @@
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/eventials/go-tus"
+	"github.com/eventials/go-tus/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConfig(t *testing.T) (Config, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	assert.Nil(t, err)
+
+	c := DefaultConfig()
+	c.Addr = mr.Addr()
+
+	return c, mr
+}
+
+func TestRedisStore(t *testing.T) {
+	c, mr := newTestConfig(t)
+	defer mr.Close()
+
+	s, err := NewRedisStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("a", "http://tus.io/uploads/1")
+	s.Set("a", "http://tus.io/uploads/2")
+
+	url, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/2", url)
+	assert.True(t, mr.Exists("tus:a"))
+	assert.Equal(t, time.Duration(0), mr.TTL("tus:a"))
+	assert.Equal(t, []string{"a"}, s.(*RedisStore).Keys())
+
+	s.Delete("a")
+
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+}
+
+func TestRedisStorePrefix(t *testing.T) {
+	c, mr := newTestConfig(t)
+	defer mr.Close()
+
+	c.Prefix = "uploads:[1]:"
+
+	s, err := NewRedisStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	mr.Set("other", "value")
+	mr.Set("uploads:2:a", "value")
+
+	s.Set("a", "http://tus.io/uploads/1")
+	s.Set("b", "http://tus.io/uploads/2")
+
+	assert.True(t, mr.Exists("uploads:[1]:a"))
+
+	keys := s.(*RedisStore).Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "b"}, keys)
+}
+
+func TestRedisStoreTTL(t *testing.T) {
+	c, mr := newTestConfig(t)
+	defer mr.Close()
+
+	c.TTL = time.Minute
+
+	s, err := NewRedisStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	s.Set("a", "http://tus.io/uploads/1")
+	assert.Equal(t, time.Minute, mr.TTL("tus:a"))
+
+	mr.FastForward(2 * time.Minute)
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+}
+
+func TestRedisUploadStore(t *testing.T) {
+	ctx := context.Background()
+
+	c, mr := newTestConfig(t)
+	defer mr.Close()
+
+	s, err := NewRedisUploadStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+
+	record := &tus.UploadRecord{
+		Fingerprint: "a",
+		Url:         "http://tus.io/uploads/1",
+		Size:        10,
+		Offset:      4,
+		Metadata:    tus.Metadata{"filename": "file.txt"},
+		PartialUrls: []string{"http://tus.io/uploads/2"},
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	assert.Nil(t, s.Set(ctx, record))
+
+	stored, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, record, stored)
+
+	assert.Nil(t, s.Set(ctx, &tus.UploadRecord{Fingerprint: "b", Url: "http://tus.io/uploads/3"}))
+
+	records, err := s.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+
+	assert.Nil(t, s.Delete(ctx, "a"))
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+}
+
+func TestRedisUploadStoreExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	c, mr := newTestConfig(t)
+	defer mr.Close()
+
+	c.TTL = time.Minute
+
+	s, err := NewRedisUploadStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	// The key expires along with the upload.
+	err = s.Set(ctx, &tus.UploadRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.Nil(t, err)
+
+	ttl := mr.TTL("tus:a")
+	assert.True(t, ttl > 59*time.Minute && ttl <= time.Hour, ttl)
+
+	// Falls back to the configured TTL.
+	err = s.Set(ctx, &tus.UploadRecord{Fingerprint: "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, mr.TTL("tus:b"))
+
+	// Expired uploads are removed.
+	err = s.Set(ctx, &tus.UploadRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(-time.Minute)})
+	assert.Nil(t, err)
+	assert.False(t, mr.Exists("tus:a"))
+}
+
+func TestRedisStoreSharedBetweenReplicas(t *testing.T) {
+	ctx := context.Background()
+
+	c, mr := newTestConfig(t)
+	defer mr.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			s, err := NewRedisUploadStore(c)
+			if !assert.Nil(t, err) {
+				return
+			}
+			defer s.Close()
+
+			for j := 0; j < 25; j++ {
+				fingerprint := fmt.Sprintf("%d-%d", i, j)
+				assert.Nil(t, s.Set(ctx, &tus.UploadRecord{Fingerprint: fingerprint, Url: fingerprint}))
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	s, err := NewRedisUploadStore(c)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	records, err := s.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, records, 100)
+
+	record, err := s.Get(ctx, "3-7")
+	assert.Nil(t, err)
+	assert.Equal(t, "3-7", record.Url)
+}
+
+func TestNilPool(t *testing.T) {
+	_, err := NewRedisStore(Config{})
+	assert.Equal(t, ErrNilPool, err)
+
+	_, err = NewRedisUploadStore(Config{})
+	assert.Equal(t, ErrNilPool, err)
+}
+
+func TestConformance(t *testing.T) {
+	c, mr := newTestConfig(t)
+	defer mr.Close()
+
+	storetest.TestStore(t, func(t *testing.T) tus.Store {
+		mr.FlushAll()
+		s, err := NewRedisStore(c)
+		assert.Nil(t, err)
+		return s
+	})
+
+	storetest.TestUploadStore(t, func(t *testing.T) tus.UploadStore {
+		mr.FlushAll()
+		s, err := NewRedisUploadStore(c)
+		assert.Nil(t, err)
+		return s
+	})
+}
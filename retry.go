@@ -0,0 +1,158 @@
+package tus
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	netUrl "net/url"
+	"time"
+)
+
+// RetryPolicy configures how the Uploader recovers from a failed chunck.
+// After each failure the Uploader waits for an exponential backoff delay, asks the
+// server for the upload offset and continues the upload from there.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of consecutive retries before giving up.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the delay between retries.
+	MaxBackoff time.Duration
+	// Multiplier increases the delay after each retry.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction of it, from 0 to 1.
+	Jitter float64
+	// RetryableStatus lists the response status codes worth retrying.
+	// Network errors are always retried and 409 covers offset mismatches.
+	RetryableStatus []int
+	// OnRetry, if set, is called before waiting for each retry.
+	OnRetry func(RetryAttempt)
+}
+
+// RetryAttempt describes a retry about to happen.
+type RetryAttempt struct {
+	// Url is the upload url.
+	Url string
+	// Attempt is the number of the retry, starting at one.
+	Attempt int
+	// Offset is the upload offset known before the failure.
+	Offset int64
+	// Delay is the time to wait before retrying.
+	Delay time.Duration
+	// Err is the error which caused the retry.
+	Err error
+}
+
+// DefaultRetryPolicy returns a RetryPolicy suitable for most uploads.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     5,
+		InitialBackoff:  500 * time.Millisecond,
+		MaxBackoff:      30 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryableStatus: []int{409, 423, 429, 500, 502, 503, 504},
+	}
+}
+
+// Validate validates the retry policy.
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 || p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.Multiplier < 1 || p.Jitter < 0 || p.Jitter > 1 {
+		return ErrRetryPolicy
+	}
+
+	return nil
+}
+
+// Retryable returns whether err is worth retrying.
+func (p *RetryPolicy) Retryable(err error) bool {
+	var clientErr ClientError
+	var urlErr *netUrl.Error
+
+	switch {
+	case err == context.Canceled || err == context.DeadlineExceeded:
+		return false
+	case err == ErrOffsetMismatch:
+		return p.retryableStatus(409)
+	case errors.As(err, &clientErr):
+		return p.retryableStatus(clientErr.Code)
+	case errors.As(err, &urlErr):
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatus {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Backoff returns the delay before the retry attempt, starting at one.
+// Without MaxBackoff the delay is limited to the largest time.Duration.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.InitialBackoff == 0 {
+		return 0
+	}
+
+	limit := float64(math.MaxInt64)
+
+	if p.MaxBackoff > 0 {
+		limit = float64(p.MaxBackoff)
+	}
+
+	delay := math.Min(float64(p.InitialBackoff)*math.Pow(p.Multiplier, float64(attempt-1)), limit)
+	delay += delay * p.Jitter * (2*rand.Float64() - 1)
+
+	// float64(math.MaxInt64) rounds up, out of the range of time.Duration.
+	if delay >= float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(delay)
+}
+
+// retry waits for the backoff delay and resynchronizes the offset with the server,
+// so the next chunck is read from the stream at the offset the server has.
+func (u *Uploader) retry(ctx context.Context, attempt int, cause error) error {
+	policy := u.client.Config.RetryPolicy
+	delay := policy.Backoff(attempt)
+
+	event := RetryAttempt{
+		Url:     u.url,
+		Attempt: attempt,
+		Offset:  u.offset,
+		Delay:   delay,
+		Err:     cause,
+	}
+
+	if policy.OnRetry != nil {
+		policy.OnRetry(event)
+	}
+
+	u.events.retry(event)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return u.syncOffset(ctx)
+}
+
+// canRetry returns whether the failure should be retried according to the retry policy.
+func (u *Uploader) canRetry(err error, attempts int) bool {
+	policy := u.client.Config.RetryPolicy
+
+	return policy != nil && attempts < policy.MaxAttempts && policy.Retryable(err) && u.running()
+}
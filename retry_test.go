@@ -0,0 +1,177 @@
+package tus
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyHandler fails the PATCH requests whose number, starting at one, is in failures.
+// A zero status drops the connection instead of answering.
+func flakyHandler(h http.Handler, failures map[int32]int) http.Handler {
+	var patches int32
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			if status, ok := failures[atomic.AddInt32(&patches, 1)]; ok {
+				if status == 0 {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+				} else {
+					w.WriteHeader(status)
+				}
+				return
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// flaky wraps a handler with flakyHandler, failing the PATCH requests in failures.
+func flaky(failures map[int32]int) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return flakyHandler(h, failures)
+	}
+}
+
+// withRetryPolicy sets the retry policy of the client.
+func withRetryPolicy(policy *RetryPolicy) func(*Config) {
+	return func(cfg *Config) {
+		cfg.RetryPolicy = policy
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     3,
+	}
+
+	assert.Nil(t, p.Validate())
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 300*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 900*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, time.Second, p.Backoff(4))
+
+	p.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		delay := p.Backoff(2)
+		assert.True(t, delay >= 150*time.Millisecond && delay <= 450*time.Millisecond)
+	}
+
+	p.Jitter = 2
+	assert.Equal(t, ErrRetryPolicy, p.Validate())
+}
+
+func TestRetryPolicyBackoffUnbounded(t *testing.T) {
+	p := &RetryPolicy{
+		MaxAttempts:    5000,
+		InitialBackoff: 100 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	assert.Nil(t, p.Validate())
+	assert.Equal(t, time.Duration(math.MaxInt64), p.Backoff(5000))
+
+	p.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, p.Backoff(5000) >= time.Duration(math.MaxInt64/2))
+	}
+
+	p.InitialBackoff = 0
+	assert.Equal(t, time.Duration(0), p.Backoff(5000))
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	p := DefaultRetryPolicy()
+
+	assert.True(t, p.Retryable(ErrOffsetMismatch))
+	assert.True(t, p.Retryable(ClientError{Code: 503}))
+	assert.False(t, p.Retryable(ClientError{Code: 400}))
+	assert.False(t, p.Retryable(ErrUploadNotFound))
+	assert.False(t, p.Retryable(context.Canceled))
+}
+
+func TestUploadRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var attempts []RetryAttempt
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.OnRetry = func(a RetryAttempt) {
+		attempts = append(attempts, a)
+	}
+
+	client, store, closeServer := newTestClient(t, flaky(map[int32]int{1: 503, 2: 0, 4: 409}), withRetryPolicy(policy))
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, uploader.Offset())
+
+	if assert.Len(t, attempts, 3) {
+		assert.Equal(t, 1, attempts[0].Attempt)
+		assert.Equal(t, 503, attempts[0].Err.(ClientError).Code)
+		assert.Equal(t, 2, attempts[1].Attempt)
+		assert.Equal(t, 1, attempts[2].Attempt)
+		assert.EqualValues(t, 8, attempts[2].Offset)
+		assert.Equal(t, ErrOffsetMismatch, attempts[2].Err)
+		assert.Equal(t, uploader.Url(), attempts[2].Url)
+	}
+
+	up, err := store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	assert.Nil(t, err)
+
+	fi, err := up.GetInfo(ctx)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, fi.Offset)
+}
+
+func TestUploadRetryGivesUp(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 2
+	policy.InitialBackoff = time.Millisecond
+
+	client, _, closeServer := newTestClient(t, flaky(map[int32]int{1: 500, 2: 500, 3: 500}), withRetryPolicy(policy))
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Equal(t, 500, err.(ClientError).Code)
+}
+
+func TestUploadRetryNotRetryable(t *testing.T) {
+	var retries int
+
+	policy := DefaultRetryPolicy()
+	policy.OnRetry = func(RetryAttempt) {
+		retries++
+	}
+
+	client, _, closeServer := newTestClient(t, flaky(map[int32]int{1: 400}), withRetryPolicy(policy))
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Equal(t, 400, err.(ClientError).Code)
+	assert.Equal(t, 0, retries)
+}
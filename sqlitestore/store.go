@@ -0,0 +1,289 @@
+// Package sqlitestore implements tus.Store and tus.UploadStore on top of a SQLite database.
+//
+// The database runs in WAL mode and every write is a short immediate transaction,
+// so several processes in the same machine can share it.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/eventials/go-tus"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// BusyTimeout is how long a connection waits for a lock held by other process.
+var BusyTimeout = 5 * time.Second
+
+// migrations are applied in order, the database user_version keeps how many were applied.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS uploads (
+		fingerprint TEXT PRIMARY KEY NOT NULL,
+		url TEXT NOT NULL DEFAULT ''
+	)`,
+	`ALTER TABLE uploads ADD COLUMN size INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE uploads ADD COLUMN upload_offset INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE uploads ADD COLUMN metadata TEXT NOT NULL DEFAULT '';
+	ALTER TABLE uploads ADD COLUMN partial_urls TEXT NOT NULL DEFAULT '';
+	ALTER TABLE uploads ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE uploads ADD COLUMN expires_at INTEGER NOT NULL DEFAULT 0`,
+}
+
+// SqliteStore implements tus.Store.
+type SqliteStore struct {
+	db *sql.DB
+}
+
+// NewSqliteStore opens the database at path, creating and migrating it if needed.
+func NewSqliteStore(path string) (tus.Store, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SqliteStore{db: db}, nil
+}
+
+func (s *SqliteStore) Get(fingerprint string) (string, bool) {
+	var url string
+	err := s.db.QueryRow(`SELECT url FROM uploads WHERE fingerprint = ?`, fingerprint).Scan(&url)
+	return url, err == nil
+}
+
+func (s *SqliteStore) Set(fingerprint, url string) {
+	s.db.Exec(`INSERT INTO uploads (fingerprint, url) VALUES (?, ?)
+		ON CONFLICT (fingerprint) DO UPDATE SET url = excluded.url`, fingerprint, url)
+}
+
+func (s *SqliteStore) Delete(fingerprint string) {
+	s.db.Exec(`DELETE FROM uploads WHERE fingerprint = ?`, fingerprint)
+}
+
+func (s *SqliteStore) Close() {
+	s.db.Close()
+}
+
+// Keys returns the fingerprints in the store.
+func (s *SqliteStore) Keys() []string {
+	var keys []string
+
+	rows, err := s.db.Query(`SELECT fingerprint FROM uploads`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if rows.Scan(&key) == nil {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// SqliteUploadStore implements tus.UploadStore, sharing the schema with SqliteStore.
+type SqliteUploadStore struct {
+	db *sql.DB
+}
+
+// NewSqliteUploadStore opens the database at path, creating and migrating it if needed.
+func NewSqliteUploadStore(path string) (tus.UploadStore, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SqliteUploadStore{db: db}, nil
+}
+
+const selectRecord = `SELECT fingerprint, url, size, upload_offset, metadata, partial_urls, created_at, expires_at FROM uploads`
+
+func (s *SqliteUploadStore) Get(ctx context.Context, fingerprint string) (*tus.UploadRecord, error) {
+	record, err := scanRecord(s.db.QueryRowContext(ctx, selectRecord+` WHERE fingerprint = ?`, fingerprint))
+	if err == sql.ErrNoRows {
+		return nil, tus.ErrUploadNotFound
+	}
+
+	return record, err
+}
+
+func (s *SqliteUploadStore) Set(ctx context.Context, record *tus.UploadRecord) error {
+	metadata, err := encodeJSON(record.Metadata)
+	if err != nil {
+		return err
+	}
+
+	partialUrls, err := encodeJSON(record.PartialUrls)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO uploads
+		(fingerprint, url, size, upload_offset, metadata, partial_urls, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (fingerprint) DO UPDATE SET
+			url = excluded.url,
+			size = excluded.size,
+			upload_offset = excluded.upload_offset,
+			metadata = excluded.metadata,
+			partial_urls = excluded.partial_urls,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at`,
+		record.Fingerprint, record.Url, record.Size, record.Offset, metadata, partialUrls,
+		encodeTime(record.CreatedAt), encodeTime(record.ExpiresAt))
+
+	return err
+}
+
+func (s *SqliteUploadStore) Delete(ctx context.Context, fingerprint string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM uploads WHERE fingerprint = ?`, fingerprint)
+	return err
+}
+
+func (s *SqliteUploadStore) List(ctx context.Context) ([]*tus.UploadRecord, error) {
+	rows, err := s.db.QueryContext(ctx, selectRecord+` ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*tus.UploadRecord
+
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+func (s *SqliteUploadStore) Close() error {
+	return s.db.Close()
+}
+
+// open opens the database in WAL mode and applies the pending migrations.
+func open(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Set("_busy_timeout", fmt.Sprint(BusyTimeout.Milliseconds()))
+	params.Set("_journal_mode", "WAL")
+	params.Set("_synchronous", "NORMAL")
+	params.Set("_txlock", "immediate")
+
+	// The path is escaped, otherwise a "?", "#" or "%" in it would be read as part of the URI.
+	escaped := (&url.URL{Path: path}).EscapedPath()
+
+	db, err := sql.Open("sqlite3", "file:"+escaped+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// migrate applies the migrations not applied yet, holding the write lock
+// so concurrent processes don't apply the same migration twice.
+func migrate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("sqlitestore: database schema version %d is newer than supported %d", version, len(migrations))
+	}
+
+	for _, m := range migrations[version:] {
+		if _, err := tx.Exec(m); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(migrations))); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecord(row scanner) (*tus.UploadRecord, error) {
+	var record tus.UploadRecord
+	var metadata, partialUrls string
+	var createdAt, expiresAt int64
+
+	err := row.Scan(&record.Fingerprint, &record.Url, &record.Size, &record.Offset,
+		&metadata, &partialUrls, &createdAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := decodeJSON(metadata, &record.Metadata); err != nil {
+		return nil, err
+	}
+
+	if err := decodeJSON(partialUrls, &record.PartialUrls); err != nil {
+		return nil, err
+	}
+
+	record.CreatedAt = decodeTime(createdAt)
+	record.ExpiresAt = decodeTime(expiresAt)
+
+	return &record, nil
+}
+
+func encodeJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func decodeJSON(s string, v interface{}) error {
+	if len(s) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(s), v)
+}
+
+func encodeTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func decodeTime(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n)
+}
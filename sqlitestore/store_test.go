@@ -0,0 +1,277 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eventials/go-tus"
+	"github.com/eventials/go-tus/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "sqlitestore")
+	assert.Nil(t, err)
+
+	return filepath.Join(dir, "tus.db"), func() { os.RemoveAll(dir) }
+}
+
+func TestSqliteStore(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	s, err := NewSqliteStore(path)
+	assert.Nil(t, err)
+
+	_, ok := s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("a", "http://tus.io/uploads/1")
+	s.Set("a", "http://tus.io/uploads/2")
+
+	url, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/2", url)
+	assert.Equal(t, []string{"a"}, s.(*SqliteStore).Keys())
+
+	s.Delete("a")
+
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+
+	s.Set("b", "http://tus.io/uploads/3")
+	s.Close()
+
+	// The entries survive reopening the database.
+	s, err = NewSqliteStore(path)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	url, ok = s.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, "http://tus.io/uploads/3", url)
+}
+
+func TestSqliteUploadStore(t *testing.T) {
+	ctx := context.Background()
+
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	s, err := NewSqliteUploadStore(path)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+
+	record := &tus.UploadRecord{
+		Fingerprint: "a",
+		Url:         "http://tus.io/uploads/1",
+		Size:        10,
+		Offset:      4,
+		Metadata:    tus.Metadata{"filename": "file.txt"},
+		PartialUrls: []string{"http://tus.io/uploads/2", "http://tus.io/uploads/3"},
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	assert.Nil(t, s.Set(ctx, record))
+	assert.Nil(t, s.Set(ctx, &tus.UploadRecord{Fingerprint: "b", Url: "http://tus.io/uploads/4"}))
+
+	stored, err := s.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, record.Url, stored.Url)
+	assert.Equal(t, record.Size, stored.Size)
+	assert.Equal(t, record.Offset, stored.Offset)
+	assert.Equal(t, record.Metadata, stored.Metadata)
+	assert.Equal(t, record.PartialUrls, stored.PartialUrls)
+	assert.True(t, record.CreatedAt.Equal(stored.CreatedAt))
+	assert.True(t, record.ExpiresAt.Equal(stored.ExpiresAt))
+
+	stored, err = s.Get(ctx, "b")
+	assert.Nil(t, err)
+	assert.Nil(t, stored.Metadata)
+	assert.Nil(t, stored.PartialUrls)
+	assert.True(t, stored.CreatedAt.IsZero())
+	assert.True(t, stored.ExpiresAt.IsZero())
+
+	records, err := s.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+
+	assert.Nil(t, s.Delete(ctx, "a"))
+	assert.Nil(t, s.Delete(ctx, "a"))
+
+	_, err = s.Get(ctx, "a")
+	assert.Equal(t, tus.ErrUploadNotFound, err)
+}
+
+func TestSqliteStoreSharedSchema(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	legacy, err := NewSqliteStore(path)
+	assert.Nil(t, err)
+	defer legacy.Close()
+
+	s, err := NewSqliteUploadStore(path)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	legacy.Set("a", "http://tus.io/uploads/1")
+
+	record, err := s.Get(context.Background(), "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://tus.io/uploads/1", record.Url)
+}
+
+func TestMigrations(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	// A database created with the first schema version only.
+	db, err := sql.Open("sqlite3", path)
+	assert.Nil(t, err)
+
+	_, err = db.Exec(migrations[0] + `; PRAGMA user_version = 1`)
+	assert.Nil(t, err)
+
+	_, err = db.Exec(`INSERT INTO uploads (fingerprint, url) VALUES ('a', 'http://tus.io/uploads/1')`)
+	assert.Nil(t, err)
+	db.Close()
+
+	s, err := NewSqliteUploadStore(path)
+	assert.Nil(t, err)
+
+	record, err := s.Get(context.Background(), "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://tus.io/uploads/1", record.Url)
+
+	db = s.(*SqliteUploadStore).db
+
+	var version int
+	assert.Nil(t, db.QueryRow(`PRAGMA user_version`).Scan(&version))
+	assert.Equal(t, len(migrations), version)
+
+	var mode string
+	assert.Nil(t, db.QueryRow(`PRAGMA journal_mode`).Scan(&mode))
+	assert.Equal(t, "wal", mode)
+
+	s.Close()
+
+	// Newer schemas are refused.
+	db, err = sql.Open("sqlite3", path)
+	assert.Nil(t, err)
+
+	_, err = db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(migrations)+1))
+	assert.Nil(t, err)
+	db.Close()
+
+	_, err = NewSqliteUploadStore(path)
+	assert.NotNil(t, err)
+}
+
+func TestSqliteStoreEscapedPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlitestore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tus?mode=memory#%41.db")
+
+	s, err := NewSqliteStore(path)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	s.Set("a", "http://tus.io/uploads/1")
+
+	// The database is at path, and the pragmas weren't lost.
+	_, err = os.Stat(path)
+	assert.Nil(t, err)
+
+	var mode string
+	err = s.(*SqliteStore).db.QueryRow(`PRAGMA journal_mode`).Scan(&mode)
+	assert.Nil(t, err)
+	assert.Equal(t, "wal", mode)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+
+	for _, f := range files {
+		assert.True(t, strings.HasPrefix(f.Name(), filepath.Base(path)), f.Name())
+	}
+}
+
+func TestSqliteStoreConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	path, cleanup := tempPath(t)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+
+	// Each store has its own connections, like separate processes.
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			s, err := NewSqliteUploadStore(path)
+			if !assert.Nil(t, err) {
+				return
+			}
+			defer s.Close()
+
+			for j := 0; j < 50; j++ {
+				fingerprint := fmt.Sprintf("%d-%d", i, j)
+
+				assert.Nil(t, s.Set(ctx, &tus.UploadRecord{Fingerprint: fingerprint, Url: fingerprint}))
+
+				record, err := s.Get(ctx, fingerprint)
+				assert.Nil(t, err)
+				assert.Equal(t, fingerprint, record.Url)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	s, err := NewSqliteUploadStore(path)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	records, err := s.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, records, 200)
+}
+
+func TestConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlitestore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var n int
+
+	storetest.TestStore(t, func(t *testing.T) tus.Store {
+		n++
+		s, err := NewSqliteStore(filepath.Join(dir, fmt.Sprintf("%d.db", n)))
+		assert.Nil(t, err)
+		return s
+	})
+
+	storetest.TestUploadStore(t, func(t *testing.T) tus.UploadStore {
+		n++
+		s, err := NewSqliteUploadStore(filepath.Join(dir, fmt.Sprintf("%d.db", n)))
+		assert.Nil(t, err)
+		return s
+	})
+}
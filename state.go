@@ -0,0 +1,166 @@
+package tus
+
+import (
+	"context"
+)
+
+// UploadState is the state of an Uploader.
+type UploadState int
+
+const (
+	// StateIdle is the state of an upload not started yet.
+	StateIdle UploadState = iota
+	// StateUploading is the state of an upload being uploaded.
+	StateUploading
+	// StatePaused is the state of an upload paused until Resume is called.
+	StatePaused
+	// StateAborted is the state of an aborted upload, it can't be continued.
+	StateAborted
+	// StateCompleted is the state of an upload whose body was entirely uploaded.
+	StateCompleted
+	// StateFailed is the state of an upload which failed, it can be uploaded again.
+	StateFailed
+)
+
+func (s UploadState) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateUploading:
+		return "uploading"
+	case StatePaused:
+		return "paused"
+	case StateAborted:
+		return "aborted"
+	case StateCompleted:
+		return "completed"
+	case StateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current state of the upload.
+func (u *Uploader) State() UploadState {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.state
+}
+
+// Pause pauses the upload process, interrupting the current chunck.
+// Upload returns ErrUploadPaused and the upload can be continued calling Resume.
+// Pausing an upload already paused does nothing.
+func (u *Uploader) Pause() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	switch u.state {
+	case StatePaused:
+		return nil
+	case StateAborted, StateCompleted:
+		return ErrUploadFinished
+	}
+
+	u.state = StatePaused
+
+	if u.cancel != nil {
+		u.cancel()
+	}
+
+	return nil
+}
+
+// Resume continues a paused upload, uploading the remaining body to the server.
+func (u *Uploader) Resume() error {
+	return u.ResumeWithContext(context.Background())
+}
+
+// ResumeWithContext continues a paused upload using the context for all requests.
+// The offset is fetched from the server first, since the interrupted chunck may have been
+// partially written.
+func (u *Uploader) ResumeWithContext(ctx context.Context) error {
+	u.mu.Lock()
+
+	if u.state != StatePaused {
+		u.mu.Unlock()
+		return ErrNotPaused
+	}
+
+	u.state = StateIdle
+	u.mu.Unlock()
+
+	if err := u.syncOffset(ctx); err != nil {
+		u.transition(StateIdle, StatePaused)
+		return err
+	}
+
+	return u.UploadWithContext(ctx)
+}
+
+// start moves the upload to StateUploading, returning a context cancelled by Pause.
+// The context is nil if the upload was aborted.
+func (u *Uploader) start(ctx context.Context) (context.Context, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	switch u.state {
+	case StateUploading:
+		return nil, ErrUploadRunning
+	case StatePaused:
+		return nil, ErrUploadPaused
+	case StateAborted:
+		return nil, nil
+	}
+
+	u.state = StateUploading
+
+	ctx, u.cancel = context.WithCancel(ctx)
+
+	return ctx, nil
+}
+
+// stop moves the upload out of StateUploading once the upload process returns,
+// returning the error Upload must return.
+func (u *Uploader) stop(err error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.cancel()
+	u.cancel = nil
+
+	switch {
+	case u.state == StatePaused:
+		return ErrUploadPaused
+	case u.state == StateAborted:
+		return err
+	case err != nil:
+		u.state = StateFailed
+	case u.finished():
+		u.state = StateCompleted
+	default:
+		u.state = StateIdle
+	}
+
+	return err
+}
+
+// transition moves the upload to the state to, if it is in the state from.
+func (u *Uploader) transition(from, to UploadState) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.state != from {
+		return false
+	}
+
+	u.state = to
+
+	return true
+}
+
+// running returns whether the upload process should continue.
+func (u *Uploader) running() bool {
+	return u.State() == StateUploading
+}
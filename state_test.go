@@ -0,0 +1,191 @@
+package tus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tus/tusd/pkg/filestore"
+)
+
+// stallingHandler stalls the given PATCH request until the client gives up on it.
+func stallingHandler(h http.Handler, stall int32, stalled chan struct{}) http.Handler {
+	var patches int32
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" && atomic.AddInt32(&patches, 1) == stall {
+			io.Copy(ioutil.Discard, r.Body)
+			close(stalled)
+
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestUploaderPauseResume(t *testing.T) {
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	stalled := make(chan struct{})
+
+	ts := httptest.NewServer(stallingHandler(newTusdHandler(store), 1, stalled))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChunkSize = 4
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+	assert.Equal(t, StateIdle, uploader.State())
+
+	assert.Equal(t, ErrNotPaused, uploader.Resume())
+
+	done := make(chan error)
+
+	go func() {
+		done <- uploader.Upload()
+	}()
+
+	<-stalled
+	assert.Equal(t, StateUploading, uploader.State())
+	assert.Equal(t, ErrUploadRunning, uploader.Upload())
+
+	// Pausing interrupts the stalled chunk.
+	assert.Nil(t, uploader.Pause())
+	assert.Nil(t, uploader.Pause())
+
+	select {
+	case err := <-done:
+		assert.Equal(t, ErrUploadPaused, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload wasn't paused")
+	}
+
+	assert.Equal(t, StatePaused, uploader.State())
+	assert.EqualValues(t, 4, uploader.Offset())
+	assert.Equal(t, ErrUploadPaused, uploader.Upload())
+
+	err = uploader.Resume()
+	assert.Nil(t, err)
+	assert.Equal(t, StateCompleted, uploader.State())
+	assert.EqualValues(t, 10, uploader.Offset())
+
+	assert.Equal(t, ErrNotPaused, uploader.Resume())
+	assert.Equal(t, ErrUploadFinished, uploader.Pause())
+
+	// Completed uploads can't be aborted.
+	uploader.Abort()
+	assert.Equal(t, StateCompleted, uploader.State())
+}
+
+func TestUploaderPauseBeforeUpload(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, nil)
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	assert.Nil(t, uploader.Pause())
+	assert.Equal(t, ErrUploadPaused, uploader.Upload())
+	assert.EqualValues(t, 4, uploader.Offset())
+
+	assert.Nil(t, uploader.ResumeWithContext(context.Background()))
+	assert.Equal(t, StateCompleted, uploader.State())
+	assert.EqualValues(t, 10, uploader.Offset())
+}
+
+func TestUploaderStateAborted(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, nil)
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	assert.Nil(t, uploader.Pause())
+
+	uploader.Abort()
+	assert.Equal(t, StateAborted, uploader.State())
+	assert.True(t, uploader.IsAborted())
+
+	assert.Nil(t, uploader.Upload())
+	assert.EqualValues(t, 4, uploader.Offset())
+	assert.Equal(t, ErrUploadFinished, uploader.Pause())
+	assert.Equal(t, ErrNotPaused, uploader.Resume())
+}
+
+func TestUploaderStateFailed(t *testing.T) {
+	client, _, closeServer := newTestClient(t, flaky(map[int32]int{1: 400}), nil)
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Equal(t, 400, err.(ClientError).Code)
+	assert.Equal(t, StateFailed, uploader.State())
+
+	// Failed uploads can be uploaded again.
+	assert.Nil(t, uploader.Upload())
+	assert.Equal(t, StateCompleted, uploader.State())
+}
+
+func TestUploadStateString(t *testing.T) {
+	assert.Equal(t, "idle", StateIdle.String())
+	assert.Equal(t, "uploading", StateUploading.String())
+	assert.Equal(t, "paused", StatePaused.String())
+	assert.Equal(t, "aborted", StateAborted.String())
+	assert.Equal(t, "completed", StateCompleted.String())
+	assert.Equal(t, "failed", StateFailed.String())
+	assert.Equal(t, "unknown", UploadState(-1).String())
+}
+
+func TestUploaderOffsetWhileUploading(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, nil)
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes(make([]byte, 64)))
+	assert.Nil(t, err)
+
+	done := make(chan struct{})
+	read := make(chan int64)
+
+	// Run with -race, the offset is read while the upload writes it.
+	go func() {
+		var offset int64
+
+		for {
+			select {
+			case <-done:
+				read <- offset
+				return
+			default:
+				offset = uploader.Offset()
+			}
+		}
+	}()
+
+	assert.Nil(t, uploader.Upload())
+	close(done)
+
+	assert.True(t, <-read <= 64)
+	assert.EqualValues(t, 64, uploader.Offset())
+}
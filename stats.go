@@ -0,0 +1,142 @@
+package tus
+
+import (
+	"sync"
+	"time"
+)
+
+// statsSmoothing is the weight of the last chunck in the moving average throughput.
+const statsSmoothing = 0.3
+
+// UploadStats is a snapshot of the throughput and timing of an upload.
+type UploadStats struct {
+	Url string
+	// Size is the upload size, -1 while it's deferred.
+	Size int64
+	// BytesSent is the amount of bytes sent to the server, including the chuncks sent again.
+	BytesSent int64
+	// BytesAcked is the amount of bytes acknowledged by the server, the upload offset.
+	BytesAcked int64
+	// Chuncks is the amount of chuncks acknowledged by the server since the upload started.
+	Chuncks int
+	// StartedAt is when Upload was last called, zero if it wasn't.
+	StartedAt time.Time
+	// Elapsed is the time since StartedAt.
+	Elapsed time.Duration
+	// LastChunckLatency is how long the last chunck took to be sent and acknowledged.
+	LastChunckLatency time.Duration
+	// AverageChunckLatency is the average time the chuncks took to be sent and acknowledged.
+	AverageChunckLatency time.Duration
+	// BytesPerSecond is the throughput of the last chunck.
+	BytesPerSecond float64
+	// AverageBytesPerSecond is the moving average throughput of the chuncks.
+	AverageBytesPerSecond float64
+	// ETA is the estimated time to finish the upload based on the moving average throughput,
+	// zero when unknown.
+	ETA time.Duration
+}
+
+// uploadStats accumulates the statistics of an Uploader.
+// It's guarded by a mutex since the statistics are read while uploading.
+type uploadStats struct {
+	mu sync.Mutex
+
+	url         string
+	size        int64
+	sent        int64
+	acked       int64
+	chuncks     int
+	startedAt   time.Time
+	startOffset int64
+	lastLatency time.Duration
+	latency     time.Duration
+	instant     float64
+	average     float64
+}
+
+// start resets the timing statistics when an upload starts.
+func (s *uploadStats) start(offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.startedAt = time.Now()
+	s.startOffset = offset
+	s.acked = offset
+	s.chuncks = 0
+	s.latency = 0
+}
+
+// chunckSent accounts a chunck sent to the server, acknowledged or not.
+func (s *uploadStats) chunckSent(length int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent += int64(length)
+}
+
+// chunckAcked accounts a chunck acknowledged by the server.
+func (s *uploadStats) chunckAcked(length int, offset, size int64, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.acked = offset
+	s.size = size
+	s.chuncks++
+	s.lastLatency = latency
+	s.latency += latency
+
+	if latency <= 0 {
+		return
+	}
+
+	s.instant = float64(length) / latency.Seconds()
+
+	if s.average == 0 {
+		s.average = s.instant
+	} else {
+		s.average = statsSmoothing*s.instant + (1-statsSmoothing)*s.average
+	}
+}
+
+// setOffset updates the offset acknowledged by the server.
+func (s *uploadStats) setOffset(offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.acked = offset
+}
+
+// Stats returns a snapshot of the upload statistics.
+// It's safe to call while the upload is running.
+func (u *Uploader) Stats() UploadStats {
+	s := &u.stats
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := UploadStats{
+		Url:                   s.url,
+		Size:                  s.size,
+		BytesSent:             s.sent,
+		BytesAcked:            s.acked,
+		Chuncks:               s.chuncks,
+		StartedAt:             s.startedAt,
+		LastChunckLatency:     s.lastLatency,
+		BytesPerSecond:        s.instant,
+		AverageBytesPerSecond: s.average,
+	}
+
+	if !s.startedAt.IsZero() {
+		stats.Elapsed = time.Since(s.startedAt)
+	}
+
+	if s.chuncks > 0 {
+		stats.AverageChunckLatency = s.latency / time.Duration(s.chuncks)
+	}
+
+	if s.size >= 0 && s.average > 0 {
+		stats.ETA = time.Duration(float64(s.size-s.acked) / s.average * float64(time.Second))
+	}
+
+	return stats
+}
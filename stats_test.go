@@ -0,0 +1,117 @@
+package tus
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadStats(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+
+	client, _, closeServer := newTestClient(t, flaky(map[int32]int{2: 503}), withRetryPolicy(policy))
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes([]byte("1234567890")))
+	assert.Nil(t, err)
+
+	stats := uploader.Stats()
+	assert.Equal(t, uploader.Url(), stats.Url)
+	assert.EqualValues(t, 10, stats.Size)
+	assert.EqualValues(t, 4, stats.BytesSent)
+	assert.EqualValues(t, 4, stats.BytesAcked)
+	assert.True(t, stats.StartedAt.IsZero())
+	assert.Zero(t, stats.ETA)
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+
+	stats = uploader.Stats()
+	assert.EqualValues(t, 10, stats.Size)
+	// The failed chunck was sent twice.
+	assert.EqualValues(t, 12, stats.BytesSent)
+	assert.EqualValues(t, 10, stats.BytesAcked)
+	assert.Equal(t, 2, stats.Chuncks)
+	assert.False(t, stats.StartedAt.IsZero())
+	assert.True(t, stats.Elapsed > 0)
+	assert.True(t, stats.LastChunckLatency > 0)
+	assert.True(t, stats.AverageChunckLatency > 0)
+	assert.True(t, stats.BytesPerSecond > 0)
+	assert.True(t, stats.AverageBytesPerSecond > 0)
+	assert.Zero(t, stats.ETA)
+}
+
+func TestUploadStatsWhileUploading(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, nil)
+	defer closeServer()
+
+	uploader, err := client.CreateUpload(NewUploadFromBytes(make([]byte, 1024)))
+	assert.Nil(t, err)
+
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		var last int64
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			stats := uploader.Stats()
+			assert.True(t, stats.BytesAcked >= last)
+			assert.True(t, stats.BytesSent >= stats.BytesAcked)
+			last = stats.BytesAcked
+
+			runtime.Gosched()
+		}
+	}()
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+
+	close(done)
+	wg.Wait()
+
+	assert.EqualValues(t, 1024, uploader.Stats().BytesAcked)
+}
+
+func TestUploadStatsMovingAverage(t *testing.T) {
+	u := NewUploader(nil, "http://tus.io/uploads/1", NewUploadFromBytes(make([]byte, 400)), 0)
+
+	u.stats.start(0)
+	u.stats.chunckAcked(100, 100, 400, time.Second)
+
+	stats := u.Stats()
+	assert.Equal(t, 100.0, stats.BytesPerSecond)
+	assert.Equal(t, 100.0, stats.AverageBytesPerSecond)
+	assert.Equal(t, 3*time.Second, stats.ETA)
+
+	u.stats.chunckAcked(100, 200, 400, 500*time.Millisecond)
+
+	stats = u.Stats()
+	assert.Equal(t, 200.0, stats.BytesPerSecond)
+	assert.InDelta(t, 130.0, stats.AverageBytesPerSecond, 0.001)
+	assert.Equal(t, 500*time.Millisecond, stats.LastChunckLatency)
+	assert.Equal(t, 750*time.Millisecond, stats.AverageChunckLatency)
+	assert.InDelta(t, float64(200)/130*float64(time.Second), float64(stats.ETA), float64(time.Millisecond))
+
+	// Deferred uploads have no estimation.
+	u = NewUploader(nil, "http://tus.io/uploads/2", NewUploadWithDeferredLength(nil, nil, ""), 0)
+	u.stats.chunckAcked(100, 100, -1, time.Second)
+
+	stats = u.Stats()
+	assert.EqualValues(t, -1, stats.Size)
+	assert.Zero(t, stats.ETA)
+}
@@ -1,8 +1,323 @@
 package tus
 
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store maps an upload's fingerprint with the corresponding upload URL.
 type Store interface {
 	Get(fingerprint string) (string, bool)
 	Set(fingerprint, url string)
 	Delete(fingerprint string)
 	Close()
 }
+
+// UploadRecord holds what is known about an upload created in the server.
+type UploadRecord struct {
+	Fingerprint string    `json:"fingerprint"`
+	Url         string    `json:"url"`
+	Size        int64     `json:"size"`
+	Offset      int64     `json:"offset"`
+	Metadata    Metadata  `json:"metadata,omitempty"`
+	PartialUrls []string  `json:"partialUrls,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// Expired returns whether the server already expired the upload.
+func (r *UploadRecord) Expired() bool {
+	return !r.ExpiresAt.IsZero() && !time.Now().Before(r.ExpiresAt)
+}
+
+// UploadStore persists upload records by fingerprint.
+// Unlike Store, all methods take a context and report failures.
+type UploadStore interface {
+	// Get returns the record of the fingerprint, or ErrUploadNotFound.
+	Get(ctx context.Context, fingerprint string) (*UploadRecord, error)
+	// Set saves the record, replacing any record with the same fingerprint.
+	Set(ctx context.Context, record *UploadRecord) error
+	// Delete removes the record of the fingerprint, if any.
+	Delete(ctx context.Context, fingerprint string) error
+	// List returns all records.
+	List(ctx context.Context) ([]*UploadRecord, error)
+	Close() error
+}
+
+// KeyLister is implemented by a Store able to list its fingerprints,
+// allowing NewUploadStore to implement UploadStore.List.
+type KeyLister interface {
+	Keys() []string
+}
+
+// expiresKeySuffix is appended to an upload fingerprint to store the upload expiration,
+// so the value of the fingerprint stays the bare upload url.
+const expiresKeySuffix = ".expires"
+
+// storeAdapter implements UploadStore on top of a Store.
+// A Store only holds strings, so only the url and the expiration of the records are saved.
+type storeAdapter struct {
+	mu    *sync.Mutex
+	store Store
+}
+
+// NewUploadStore adapts a Store to an UploadStore.
+// The url of the records is saved under the fingerprint, so the Store stays readable by its
+// other users, and the expiration under the fingerprint with the ".expires" suffix.
+// Records are read with only these fields set. Accesses to the Store are serialized.
+func NewUploadStore(s Store) UploadStore {
+	return &storeAdapter{
+		mu:    new(sync.Mutex),
+		store: s,
+	}
+}
+
+func (s *storeAdapter) Get(ctx context.Context, fingerprint string) (*UploadRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.get(fingerprint)
+}
+
+func (s *storeAdapter) get(fingerprint string) (*UploadRecord, error) {
+	value, found := s.store.Get(fingerprint)
+
+	if !found {
+		return nil, ErrUploadNotFound
+	}
+
+	record := &UploadRecord{
+		Fingerprint: fingerprint,
+		Url:         value,
+	}
+
+	if expires, ok := s.store.Get(fingerprint + expiresKeySuffix); ok {
+		record.ExpiresAt, _ = time.Parse(time.RFC3339, expires)
+	}
+
+	return record, nil
+}
+
+func (s *storeAdapter) Set(ctx context.Context, record *UploadRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A parallel upload has no url until it's concatenated, its partial uploads are saved instead.
+	if len(record.Url) == 0 {
+		s.store.Delete(record.Fingerprint)
+		s.store.Delete(record.Fingerprint + expiresKeySuffix)
+		return nil
+	}
+
+	s.store.Set(record.Fingerprint, record.Url)
+
+	if record.ExpiresAt.IsZero() {
+		s.store.Delete(record.Fingerprint + expiresKeySuffix)
+	} else {
+		s.store.Set(record.Fingerprint+expiresKeySuffix, record.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (s *storeAdapter) Delete(ctx context.Context, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store.Delete(fingerprint)
+	s.store.Delete(fingerprint + expiresKeySuffix)
+
+	return nil
+}
+
+func (s *storeAdapter) List(ctx context.Context) ([]*UploadRecord, error) {
+	lister, ok := s.store.(KeyLister)
+
+	if !ok {
+		return nil, ErrListNotSupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []*UploadRecord
+
+	for _, key := range lister.Keys() {
+		if strings.HasSuffix(key, expiresKeySuffix) {
+			continue
+		}
+
+		record, err := s.get(key)
+
+		if err == ErrUploadNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (s *storeAdapter) Close() error {
+	s.store.Close()
+	return nil
+}
+
+// scopedStore saves the records of an UploadStore under keys prefixed by the endpoint and scope,
+// while its callers only see the upload fingerprints.
+type scopedStore struct {
+	store  UploadStore
+	prefix string
+}
+
+func (s *scopedStore) Get(ctx context.Context, fingerprint string) (*UploadRecord, error) {
+	record, err := s.store.Get(ctx, s.prefix+fingerprint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := *record
+	r.Fingerprint = fingerprint
+
+	return &r, nil
+}
+
+func (s *scopedStore) Set(ctx context.Context, record *UploadRecord) error {
+	r := *record
+	r.Fingerprint = s.prefix + record.Fingerprint
+
+	return s.store.Set(ctx, &r)
+}
+
+func (s *scopedStore) Delete(ctx context.Context, fingerprint string) error {
+	return s.store.Delete(ctx, s.prefix+fingerprint)
+}
+
+func (s *scopedStore) List(ctx context.Context) ([]*UploadRecord, error) {
+	records, err := s.store.List(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var scoped []*UploadRecord
+
+	for _, record := range records {
+		if !strings.HasPrefix(record.Fingerprint, s.prefix) {
+			continue
+		}
+
+		r := *record
+		r.Fingerprint = strings.TrimPrefix(record.Fingerprint, s.prefix)
+
+		scoped = append(scoped, &r)
+	}
+
+	return scoped, nil
+}
+
+func (s *scopedStore) Close() error {
+	return s.store.Close()
+}
+
+// baseStore returns the configured store, shared by all endpoints.
+func (c *Client) baseStore() UploadStore {
+	if c.Config.UploadStore != nil {
+		return c.Config.UploadStore
+	}
+
+	return &storeAdapter{
+		mu:    &c.storeMu,
+		store: c.Config.Store,
+	}
+}
+
+// storeKeyPrefix returns the prefix of the keys of this client's uploads.
+// Urls don't have spaces and the scope is escaped, so distinct endpoints and scopes never share keys.
+func (c *Client) storeKeyPrefix() string {
+	return c.Url + " " + url.PathEscape(c.Config.StoreScope) + " "
+}
+
+// uploadStore returns the store used to resume uploads, scoped to this client.
+func (c *Client) uploadStore() UploadStore {
+	return &scopedStore{
+		store:  c.baseStore(),
+		prefix: c.storeKeyPrefix(),
+	}
+}
+
+// migrateRecord moves a record saved by previous versions under the bare fingerprint
+// to this client's scope, if the upload belongs to the client's endpoint.
+// Records are only migrated when no scope is configured, as they could belong to any scope.
+func (c *Client) migrateRecord(ctx context.Context, fingerprint string) (*UploadRecord, error) {
+	if len(c.Config.StoreScope) > 0 {
+		return nil, ErrUploadNotFound
+	}
+
+	base := c.baseStore()
+
+	record, err := base.Get(ctx, fingerprint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	urls := append([]string{record.Url}, record.PartialUrls...)
+
+	for _, u := range urls {
+		if len(u) > 0 && !strings.HasPrefix(u, c.Url) {
+			return nil, ErrUploadNotFound
+		}
+	}
+
+	if err := c.uploadStore().Set(ctx, record); err != nil {
+		return nil, err
+	}
+
+	if err := base.Delete(ctx, fingerprint); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// saveRecord saves the upload record in the store.
+func (c *Client) saveRecord(ctx context.Context, record *UploadRecord) error {
+	return c.uploadStore().Set(ctx, record)
+}
+
+// loadRecord returns the stored upload record.
+// Uploads already expired are removed from the store and reported as not found.
+func (c *Client) loadRecord(ctx context.Context, fingerprint string) (*UploadRecord, error) {
+	record, err := c.uploadStore().Get(ctx, fingerprint)
+
+	if err == ErrUploadNotFound {
+		record, err = c.migrateRecord(ctx, fingerprint)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Expired() {
+		if err := c.deleteRecord(ctx, fingerprint); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrUploadNotFound
+	}
+
+	return record, nil
+}
+
+// deleteRecord removes the upload record from the store.
+func (c *Client) deleteRecord(ctx context.Context, fingerprint string) error {
+	return c.uploadStore().Delete(ctx, fingerprint)
+}
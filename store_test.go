@@ -0,0 +1,222 @@
+package tus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type listingMockStore struct {
+	*MockStore
+}
+
+func (s listingMockStore) Keys() []string {
+	var keys []string
+
+	for k := range s.m {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func TestUploadStoreRecord(t *testing.T) {
+	ctx := context.Background()
+	s := NewMockStore()
+	store := NewUploadStore(s)
+
+	_, err := store.Get(ctx, "fingerprint")
+	assert.Equal(t, ErrUploadNotFound, err)
+
+	record := &UploadRecord{
+		Fingerprint: "fingerprint",
+		Url:         "http://tus.io/uploads/1",
+		Size:        10,
+		Offset:      4,
+		Metadata:    Metadata{"filename": "file.txt"},
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().UTC().Add(time.Hour).Truncate(time.Second),
+	}
+
+	err = store.Set(ctx, record)
+	assert.Nil(t, err)
+
+	// The fingerprint keeps only the url, as before the UploadStore existed.
+	url, found := s.Get("fingerprint")
+	assert.True(t, found)
+	assert.Equal(t, "http://tus.io/uploads/1", url)
+
+	stored, err := store.Get(ctx, "fingerprint")
+	assert.Nil(t, err)
+	assert.Equal(t, "fingerprint", stored.Fingerprint)
+	assert.Equal(t, "http://tus.io/uploads/1", stored.Url)
+	assert.True(t, record.ExpiresAt.Equal(stored.ExpiresAt))
+	assert.Zero(t, stored.Size)
+	assert.Nil(t, stored.Metadata)
+
+	// Saving a record without expiration removes the saved one.
+	err = store.Set(ctx, &UploadRecord{Fingerprint: "fingerprint", Url: "http://tus.io/uploads/1"})
+	assert.Nil(t, err)
+
+	_, found = s.Get("fingerprint" + expiresKeySuffix)
+	assert.False(t, found)
+
+	err = store.Set(ctx, record)
+	assert.Nil(t, err)
+
+	err = store.Delete(ctx, "fingerprint")
+	assert.Nil(t, err)
+
+	_, err = store.Get(ctx, "fingerprint")
+	assert.Equal(t, ErrUploadNotFound, err)
+	assert.Empty(t, s.(*MockStore).m)
+}
+
+func TestUploadStoreWithoutUrl(t *testing.T) {
+	ctx := context.Background()
+	s := NewMockStore()
+	s.Set("parallel", "http://tus.io/uploads/1")
+
+	store := NewUploadStore(s)
+
+	err := store.Set(ctx, &UploadRecord{
+		Fingerprint: "parallel",
+		PartialUrls: []string{"http://tus.io/uploads/2", "http://tus.io/uploads/3"},
+	})
+	assert.Nil(t, err)
+
+	_, found := s.Get("parallel")
+	assert.False(t, found)
+}
+
+func TestUploadStoreList(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewUploadStore(NewMockStore()).List(ctx)
+	assert.Equal(t, ErrListNotSupported, err)
+
+	s := listingMockStore{NewMockStore().(*MockStore)}
+	s.Set("legacy", "http://tus.io/uploads/1")
+
+	store := NewUploadStore(s)
+
+	err = store.Set(ctx, &UploadRecord{Fingerprint: "record", Url: "http://tus.io/uploads/2", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.Nil(t, err)
+
+	records, err := store.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+
+	urls := map[string]string{}
+
+	for _, r := range records {
+		urls[r.Fingerprint] = r.Url
+	}
+
+	assert.Equal(t, map[string]string{
+		"legacy": "http://tus.io/uploads/1",
+		"record": "http://tus.io/uploads/2",
+	}, urls)
+}
+
+func TestConfigUploadStore(t *testing.T) {
+	store := NewUploadStore(NewMockStore())
+
+	cfg := DefaultConfig()
+	cfg.Resume = true
+	cfg.UploadStore = store
+
+	assert.Nil(t, cfg.Validate())
+
+	client, err := NewClient("http://tus.io/uploads", cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, store, client.baseStore())
+}
+
+func newScopedTestClient(t *testing.T, url, scope string, store Store) *Client {
+	cfg := DefaultConfig()
+	cfg.Resume = true
+	cfg.Store = store
+	cfg.StoreScope = scope
+
+	client, err := NewClient(url, cfg)
+	assert.Nil(t, err)
+
+	return client
+}
+
+func TestStoreScope(t *testing.T) {
+	ctx := context.Background()
+	store := listingMockStore{NewMockStore().(*MockStore)}
+
+	clients := []*Client{
+		newScopedTestClient(t, "http://first.tus.io/files/", "", store),
+		newScopedTestClient(t, "http://second.tus.io/files/", "", store),
+		newScopedTestClient(t, "http://second.tus.io/files/", "tenant 1", store),
+		newScopedTestClient(t, "http://second.tus.io/files/", "tenant 2", store),
+	}
+
+	for i, c := range clients {
+		err := c.saveRecord(ctx, &UploadRecord{Fingerprint: "fingerprint", Url: c.Url + fmt.Sprint(i)})
+		assert.Nil(t, err)
+	}
+
+	assert.Len(t, store.Keys(), len(clients))
+
+	for i, c := range clients {
+		record, err := c.loadRecord(ctx, "fingerprint")
+		assert.Nil(t, err)
+		assert.Equal(t, "fingerprint", record.Fingerprint)
+		assert.Equal(t, c.Url+fmt.Sprint(i), record.Url)
+
+		records, err := c.uploadStore().List(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, records, 1)
+		assert.Equal(t, "fingerprint", records[0].Fingerprint)
+	}
+
+	err := clients[0].deleteRecord(ctx, "fingerprint")
+	assert.Nil(t, err)
+
+	_, err = clients[1].loadRecord(ctx, "fingerprint")
+	assert.Nil(t, err)
+}
+
+func TestStoreScopeMigratesLegacyRecords(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMockStore()
+	store.Set("first", "http://first.tus.io/files/1")
+	store.Set("second", "http://second.tus.io/files/1")
+
+	client := newScopedTestClient(t, "http://first.tus.io/files/", "", store)
+
+	// Uploads of other endpoints are kept as they are.
+	_, err := client.loadRecord(ctx, "second")
+	assert.Equal(t, ErrUploadNotFound, err)
+
+	_, found := store.Get("second")
+	assert.True(t, found)
+
+	record, err := client.loadRecord(ctx, "first")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", record.Fingerprint)
+	assert.Equal(t, "http://first.tus.io/files/1", record.Url)
+
+	_, found = store.Get("first")
+	assert.False(t, found)
+
+	_, found = store.Get(client.storeKeyPrefix() + "first")
+	assert.True(t, found)
+
+	// Scoped clients can't tell the scope of the legacy records.
+	store.Set("scoped", "http://first.tus.io/files/2")
+
+	client = newScopedTestClient(t, "http://first.tus.io/files/", "tenant", store)
+
+	_, err = client.loadRecord(ctx, "scoped")
+	assert.Equal(t, ErrUploadNotFound, err)
+}
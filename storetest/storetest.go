@@ -0,0 +1,351 @@
+// Package storetest implements conformance tests for tus.Store and tus.UploadStore implementations.
+//
+// A Store implementation runs the suite from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.TestStore(t, func(t *testing.T) tus.Store {
+//			s, err := NewMyStore()
+//			if err != nil {
+//				t.Fatal(err)
+//			}
+//			return s
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eventials/go-tus"
+	"github.com/stretchr/testify/assert"
+)
+
+// Concurrency is the number of goroutines used by the concurrent access tests.
+var Concurrency = 8
+
+// closeTimeout is how long Close may take before the test fails.
+const closeTimeout = 10 * time.Second
+
+// fingerprints are the fingerprints stored by the tests, besides the plain ones.
+var fingerprints = map[string]string{
+	"large":   strings.Repeat("0123456789abcdef", 256),
+	"unicode": "/home/usuário/vídeos/日本語の ファイル 🎥.mp4-1024-1588000000",
+	"path":    "/var/data/my uploads/file.txt\t2048",
+}
+
+// TestStore runs the conformance tests against the stores created by newStore.
+// Each test uses a new store and closes it at the end.
+func TestStore(t *testing.T, newStore func(t *testing.T) tus.Store) {
+	run := func(name string, test func(t *testing.T, s tus.Store)) {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			test(t, s)
+			closeStore(t, func() error { s.Close(); return nil })
+		})
+	}
+
+	run("GetMiss", func(t *testing.T, s tus.Store) {
+		url, ok := s.Get("missing")
+		assert.False(t, ok)
+		assert.Empty(t, url)
+	})
+
+	run("SetGet", func(t *testing.T, s tus.Store) {
+		s.Set("fingerprint", "http://tus.io/uploads/1")
+
+		url, ok := s.Get("fingerprint")
+		assert.True(t, ok)
+		assert.Equal(t, "http://tus.io/uploads/1", url)
+	})
+
+	run("Overwrite", func(t *testing.T, s tus.Store) {
+		s.Set("fingerprint", "http://tus.io/uploads/1")
+		s.Set("fingerprint", "http://tus.io/uploads/2")
+
+		url, ok := s.Get("fingerprint")
+		assert.True(t, ok)
+		assert.Equal(t, "http://tus.io/uploads/2", url)
+	})
+
+	run("Delete", func(t *testing.T, s tus.Store) {
+		s.Set("fingerprint", "http://tus.io/uploads/1")
+		s.Set("other", "http://tus.io/uploads/2")
+		s.Delete("fingerprint")
+		s.Delete("missing")
+
+		_, ok := s.Get("fingerprint")
+		assert.False(t, ok)
+
+		url, ok := s.Get("other")
+		assert.True(t, ok)
+		assert.Equal(t, "http://tus.io/uploads/2", url)
+	})
+
+	run("Fingerprints", func(t *testing.T, s tus.Store) {
+		for name, fingerprint := range fingerprints {
+			s.Set(fingerprint, "http://tus.io/uploads/"+name)
+		}
+
+		for name, fingerprint := range fingerprints {
+			url, ok := s.Get(fingerprint)
+			assert.True(t, ok, name)
+			assert.Equal(t, "http://tus.io/uploads/"+name, url, name)
+		}
+	})
+
+	run("UnicodeUrl", func(t *testing.T, s tus.Store) {
+		s.Set("fingerprint", "http://tus.io/uploads/日本語?name=vídeo")
+
+		url, ok := s.Get("fingerprint")
+		assert.True(t, ok)
+		assert.Equal(t, "http://tus.io/uploads/日本語?name=vídeo", url)
+	})
+
+	run("Keys", func(t *testing.T, s tus.Store) {
+		lister, ok := s.(tus.KeyLister)
+
+		if !ok {
+			t.Skip("store doesn't implement tus.KeyLister")
+		}
+
+		s.Set("a", "http://tus.io/uploads/1")
+		s.Set("b", "http://tus.io/uploads/2")
+		s.Set(fingerprints["unicode"], "http://tus.io/uploads/3")
+		s.Delete("b")
+
+		keys := lister.Keys()
+		sort.Strings(keys)
+
+		expected := []string{"a", fingerprints["unicode"]}
+		sort.Strings(expected)
+
+		assert.Equal(t, expected, keys)
+	})
+
+	run("Concurrent", func(t *testing.T, s tus.Store) {
+		concurrently(func(i, j int) {
+			fingerprint := fmt.Sprintf("fingerprint-%d-%d", i, j)
+			url := fmt.Sprintf("http://tus.io/uploads/%d/%d", i, j)
+
+			s.Set(fingerprint, url)
+
+			stored, ok := s.Get(fingerprint)
+			assert.True(t, ok, fingerprint)
+			assert.Equal(t, url, stored, fingerprint)
+
+			if j%2 == 0 {
+				s.Delete(fingerprint)
+
+				_, ok := s.Get(fingerprint)
+				assert.False(t, ok, fingerprint)
+			}
+		})
+	})
+}
+
+// TestUploadStore runs the conformance tests against the upload stores created by newStore.
+// Each test uses a new store and closes it at the end.
+func TestUploadStore(t *testing.T, newStore func(t *testing.T) tus.UploadStore) {
+	ctx := context.Background()
+
+	run := func(name string, test func(t *testing.T, s tus.UploadStore)) {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			test(t, s)
+			closeStore(t, s.Close)
+		})
+	}
+
+	run("GetMiss", func(t *testing.T, s tus.UploadStore) {
+		record, err := s.Get(ctx, "missing")
+		assert.Equal(t, tus.ErrUploadNotFound, err)
+		assert.Nil(t, record)
+	})
+
+	run("SetGet", func(t *testing.T, s tus.UploadStore) {
+		record := newRecord("fingerprint")
+
+		assert.Nil(t, s.Set(ctx, record))
+
+		stored, err := s.Get(ctx, "fingerprint")
+		assert.Nil(t, err)
+		assertRecord(t, record, stored)
+
+		empty := &tus.UploadRecord{Fingerprint: "empty"}
+
+		assert.Nil(t, s.Set(ctx, empty))
+
+		stored, err = s.Get(ctx, "empty")
+		assert.Nil(t, err)
+		assertRecord(t, empty, stored)
+	})
+
+	run("Overwrite", func(t *testing.T, s tus.UploadStore) {
+		record := newRecord("fingerprint")
+
+		assert.Nil(t, s.Set(ctx, record))
+
+		record.Url = "http://tus.io/uploads/2"
+		record.Offset = record.Size
+		record.PartialUrls = nil
+
+		assert.Nil(t, s.Set(ctx, record))
+
+		stored, err := s.Get(ctx, "fingerprint")
+		assert.Nil(t, err)
+		assertRecord(t, record, stored)
+	})
+
+	run("Delete", func(t *testing.T, s tus.UploadStore) {
+		assert.Nil(t, s.Set(ctx, newRecord("fingerprint")))
+		assert.Nil(t, s.Set(ctx, newRecord("other")))
+		assert.Nil(t, s.Delete(ctx, "fingerprint"))
+		assert.Nil(t, s.Delete(ctx, "missing"))
+
+		_, err := s.Get(ctx, "fingerprint")
+		assert.Equal(t, tus.ErrUploadNotFound, err)
+
+		_, err = s.Get(ctx, "other")
+		assert.Nil(t, err)
+	})
+
+	run("Fingerprints", func(t *testing.T, s tus.UploadStore) {
+		for _, fingerprint := range fingerprints {
+			assert.Nil(t, s.Set(ctx, newRecord(fingerprint)))
+		}
+
+		for name, fingerprint := range fingerprints {
+			stored, err := s.Get(ctx, fingerprint)
+			if assert.Nil(t, err, name) {
+				assertRecord(t, newRecord(fingerprint), stored)
+			}
+		}
+	})
+
+	run("List", func(t *testing.T, s tus.UploadStore) {
+		assert.Nil(t, s.Set(ctx, newRecord("a")))
+		assert.Nil(t, s.Set(ctx, newRecord("b")))
+		assert.Nil(t, s.Set(ctx, newRecord(fingerprints["unicode"])))
+		assert.Nil(t, s.Delete(ctx, "b"))
+
+		records, err := s.List(ctx)
+
+		if err == tus.ErrListNotSupported {
+			t.Skip("store can't list its records")
+		}
+
+		assert.Nil(t, err)
+
+		var listed []string
+
+		for _, r := range records {
+			listed = append(listed, r.Fingerprint)
+		}
+
+		sort.Strings(listed)
+
+		expected := []string{"a", fingerprints["unicode"]}
+		sort.Strings(expected)
+
+		assert.Equal(t, expected, listed)
+	})
+
+	run("Concurrent", func(t *testing.T, s tus.UploadStore) {
+		concurrently(func(i, j int) {
+			record := newRecord(fmt.Sprintf("fingerprint-%d-%d", i, j))
+
+			assert.Nil(t, s.Set(ctx, record))
+
+			stored, err := s.Get(ctx, record.Fingerprint)
+			if assert.Nil(t, err, record.Fingerprint) {
+				assertRecord(t, record, stored)
+			}
+
+			if j%2 == 0 {
+				assert.Nil(t, s.Delete(ctx, record.Fingerprint))
+
+				_, err := s.Get(ctx, record.Fingerprint)
+				assert.Equal(t, tus.ErrUploadNotFound, err, record.Fingerprint)
+			}
+		})
+	})
+}
+
+// newRecord returns a record with all fields set.
+func newRecord(fingerprint string) *tus.UploadRecord {
+	now := time.Now().Truncate(time.Second)
+
+	return &tus.UploadRecord{
+		Fingerprint: fingerprint,
+		Url:         "http://tus.io/uploads/1",
+		Size:        1024,
+		Offset:      512,
+		Metadata:    tus.Metadata{"filename": "vídeo.mp4", "type": "video/mp4"},
+		PartialUrls: []string{"http://tus.io/uploads/2", "http://tus.io/uploads/3"},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+}
+
+// assertRecord compares the records field by field, as the time locations may change.
+func assertRecord(t *testing.T, expected, actual *tus.UploadRecord) {
+	assert.Equal(t, expected.Fingerprint, actual.Fingerprint)
+	assert.Equal(t, expected.Url, actual.Url)
+	assert.Equal(t, expected.Size, actual.Size)
+	assert.Equal(t, expected.Offset, actual.Offset)
+	assert.Equal(t, len(expected.Metadata), len(actual.Metadata))
+
+	for k, v := range expected.Metadata {
+		assert.Equal(t, v, actual.Metadata[k])
+	}
+
+	assert.Equal(t, len(expected.PartialUrls), len(actual.PartialUrls))
+
+	if len(expected.PartialUrls) > 0 {
+		assert.Equal(t, expected.PartialUrls, actual.PartialUrls)
+	}
+
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "CreatedAt %v != %v", expected.CreatedAt, actual.CreatedAt)
+	assert.True(t, expected.ExpiresAt.Equal(actual.ExpiresAt), "ExpiresAt %v != %v", expected.ExpiresAt, actual.ExpiresAt)
+}
+
+// concurrently calls fn from Concurrency goroutines, 20 times each.
+func concurrently(fn func(i, j int)) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < Concurrency; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				fn(i, j)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// closeStore fails the test if Close returns an error or doesn't return.
+func closeStore(t *testing.T, close func() error) {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- close()
+	}()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err, "Close")
+	case <-time.After(closeTimeout):
+		t.Fatal("Close didn't return")
+	}
+}
@@ -0,0 +1,55 @@
+package tus
+
+import (
+	"io"
+)
+
+// bufferedStream adapts an io.Reader to an io.ReadSeeker without reading the whole reader
+// into memory. It keeps the bytes read since the last seek, so the chunck not yet
+// acknowledged by the server can be read again, and discards everything before it.
+type bufferedStream struct {
+	reader io.Reader
+	buf    []byte
+	start  int64 // offset of buf[0]
+	pos    int64
+}
+
+func newBufferedStream(reader io.Reader) *bufferedStream {
+	return &bufferedStream{reader: reader}
+}
+
+func (s *bufferedStream) Read(p []byte) (int, error) {
+	// Read again the buffered bytes first.
+	if end := s.start + int64(len(s.buf)); s.pos < end {
+		n := copy(p, s.buf[s.pos-s.start:])
+		s.pos += int64(n)
+
+		return n, nil
+	}
+
+	n, err := s.reader.Read(p)
+	s.buf = append(s.buf, p[:n]...)
+	s.pos += int64(n)
+
+	return n, err
+}
+
+// Seek moves to an offset within the buffered window, discarding the bytes before it.
+func (s *bufferedStream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		return -1, ErrStreamNotSeekable
+	}
+
+	if offset < s.start || offset > s.start+int64(len(s.buf)) {
+		return -1, ErrOffsetOutsideBuffer
+	}
+
+	s.buf = append(s.buf[:0], s.buf[offset-s.start:]...)
+	s.start = offset
+	s.pos = offset
+
+	return offset, nil
+}
@@ -0,0 +1,63 @@
+package tus
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferedStream(t *testing.T) {
+	s := newBufferedStream(bytes.NewBufferString("1234567890"))
+
+	b := make([]byte, 4)
+
+	n, err := io.ReadFull(s, b)
+	assert.Nil(t, err)
+	assert.Equal(t, "1234", string(b[:n]))
+
+	// The chunck can be read again from any offset not acknowledged.
+	offset, err := s.Seek(2, io.SeekStart)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, offset)
+
+	n, err = io.ReadFull(s, b)
+	assert.Nil(t, err)
+	assert.Equal(t, "3456", string(b[:n]))
+
+	offset, err = s.Seek(0, io.SeekCurrent)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 6, offset)
+
+	// Bytes before the last seek are discarded.
+	_, err = s.Seek(1, io.SeekStart)
+	assert.Equal(t, ErrOffsetOutsideBuffer, err)
+
+	_, err = s.Seek(7, io.SeekStart)
+	assert.Equal(t, ErrOffsetOutsideBuffer, err)
+
+	_, err = s.Seek(0, io.SeekEnd)
+	assert.Equal(t, ErrStreamNotSeekable, err)
+
+	rest, err := ioutil.ReadAll(s)
+	assert.Nil(t, err)
+	assert.Equal(t, "7890", string(rest))
+}
+
+func TestBufferedStreamBounded(t *testing.T) {
+	s := newBufferedStream(bytes.NewReader(make([]byte, 1024*1024)))
+
+	b := make([]byte, 1024)
+
+	for offset := int64(0); offset < 1024*1024; offset += 1024 {
+		_, err := s.Seek(offset, io.SeekStart)
+		assert.Nil(t, err)
+
+		_, err = io.ReadFull(s, b)
+		assert.Nil(t, err)
+	}
+
+	assert.True(t, cap(s.buf) <= 2048)
+}
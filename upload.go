@@ -7,14 +7,17 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Metadata map[string]string
 
 type Upload struct {
-	stream io.ReadSeeker
-	size   int64
-	offset int64
+	stream         io.ReadSeeker
+	size           int64
+	sizeIsDeferred bool
+	offset         int64
+	fingerprinted  bool
 
 	Fingerprint string
 	Metadata    Metadata
@@ -27,11 +30,20 @@ func (u *Upload) updateProgress(offset int64) {
 
 // Returns whether this upload is finished or not.
 func (u *Upload) Finished() bool {
-	return u.offset >= u.size
+	return !u.sizeIsDeferred && u.offset >= u.size
 }
 
 // Returns the progress in a percentage.
+// It is always zero while the size is deferred, and 100 for empty uploads.
 func (u *Upload) Progress() int64 {
+	if u.sizeIsDeferred {
+		return 0
+	}
+
+	if u.size == 0 {
+		return 100
+	}
+
 	return (u.offset * 100) / u.size
 }
 
@@ -40,11 +52,22 @@ func (u *Upload) Offset() int64 {
 	return u.offset
 }
 
-// Returns the size of the upload body.
+// Returns the size of the upload body, or -1 while the size is deferred.
 func (u *Upload) Size() int64 {
 	return u.size
 }
 
+// Returns whether the size of the upload body is unknown until the whole body is read.
+func (u *Upload) SizeIsDeferred() bool {
+	return u.sizeIsDeferred
+}
+
+// declareSize sets the size of an upload created with a deferred length.
+func (u *Upload) declareSize(size int64) {
+	u.size = size
+	u.sizeIsDeferred = false
+}
+
 // EncodedMetadata encodes the upload metadata.
 func (u *Upload) EncodedMetadata() string {
 	var encoded []string
@@ -56,6 +79,113 @@ func (u *Upload) EncodedMetadata() string {
 	return strings.Join(encoded, ",")
 }
 
+// readChunck reads up to size bytes of the upload body starting at offset.
+// Less than size bytes are returned only when the end of the body is reached.
+// Bodies implementing io.ReaderAt are read without seeking, so they may be read concurrently.
+func (u *Upload) readChunck(offset, size int64) ([]byte, error) {
+	data := make([]byte, size)
+
+	if r, ok := u.readerAt(); ok {
+		n, err := r.ReadAt(data, offset)
+
+		if err == io.EOF && (n > 0 || u.sizeIsDeferred) {
+			err = nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return data[:n], nil
+	}
+
+	if _, err := u.stream.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	n, err := io.ReadFull(u.stream, data)
+
+	// The end of a deferred upload is only known when an empty chunck is read.
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && u.sizeIsDeferred) {
+		err = nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return data[:n], nil
+}
+
+// readerAt returns the upload body as an io.ReaderAt, if it implements it.
+func (u *Upload) readerAt() (io.ReaderAt, bool) {
+	r, ok := u.stream.(io.ReaderAt)
+	return r, ok
+}
+
+// seekable returns whether the upload body can be read at any offset.
+func (u *Upload) seekable() bool {
+	_, ok := u.stream.(*bufferedStream)
+	return !ok
+}
+
+// partial creates an Upload for the section of this upload body starting at offset.
+// Partial uploads may be read concurrently.
+func (u *Upload) partial(offset, size int64, fingerprint string) *Upload {
+	readerAt, ok := u.stream.(io.ReaderAt)
+
+	if !ok {
+		readerAt = &lockedReaderAt{stream: u.stream}
+		u.stream = readerAt.(io.ReadSeeker)
+	}
+
+	return &Upload{
+		stream: io.NewSectionReader(readerAt, offset, size),
+		size:   size,
+
+		Fingerprint: fingerprint,
+		Metadata:    make(Metadata),
+	}
+}
+
+// lockedReaderAt implements io.ReaderAt on top of an io.ReadSeeker,
+// serializing the seeks and reads of concurrent callers.
+type lockedReaderAt struct {
+	mu     sync.Mutex
+	stream io.ReadSeeker
+}
+
+func (r *lockedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.stream.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(r.stream, p)
+
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (r *lockedReaderAt) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stream.Read(p)
+}
+
+func (r *lockedReaderAt) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stream.Seek(offset, whence)
+}
+
 // NewUploadFromFile creates a new Upload from an os.File.
 func NewUploadFromFile(f *os.File) (*Upload, error) {
 	fi, err := f.Stat()
@@ -80,13 +210,13 @@ func NewUploadFromBytes(b []byte) *Upload {
 }
 
 // NewUpload creates a new upload from an io.Reader.
+// A reader which isn't an io.ReadSeeker is streamed chunck by chunck, keeping in memory only
+// the chunck being sent so it can be sent again after a failure.
 func NewUpload(reader io.Reader, size int64, metadata Metadata, fingerprint string) *Upload {
 	stream, ok := reader.(io.ReadSeeker)
 
 	if !ok {
-		buf := new(bytes.Buffer)
-		buf.ReadFrom(reader)
-		stream = bytes.NewReader(buf.Bytes())
+		stream = newBufferedStream(reader)
 	}
 
 	if metadata == nil {
@@ -102,6 +232,30 @@ func NewUpload(reader io.Reader, size int64, metadata Metadata, fingerprint stri
 	}
 }
 
+// NewUploadWithDeferredLength creates a new upload from an io.Reader whose size is unknown.
+// The size is declared to the server once the end of the reader is reached.
+// It requires the server to support the Creation Defer Length extension.
+func NewUploadWithDeferredLength(reader io.Reader, metadata Metadata, fingerprint string) *Upload {
+	stream, ok := reader.(io.ReadSeeker)
+
+	if !ok {
+		stream = newBufferedStream(reader)
+	}
+
+	if metadata == nil {
+		metadata = make(Metadata)
+	}
+
+	return &Upload{
+		stream:         stream,
+		size:           -1,
+		sizeIsDeferred: true,
+
+		Fingerprint: fingerprint,
+		Metadata:    metadata,
+	}
+}
+
 func b64encode(s string) string {
 	return base64.StdEncoding.EncodeToString([]byte(s))
 }
@@ -1,6 +1,7 @@
 package tus
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"testing"
@@ -30,3 +31,18 @@ func TestNewUploadFromFile(t *testing.T) {
 	assert.NotNil(t, u)
 	assert.EqualValues(t, 1048576, u.Size())
 }
+
+func TestProgress(t *testing.T) {
+	u := NewUploadFromBytes([]byte("1234567890"))
+	assert.EqualValues(t, 0, u.Progress())
+
+	u.updateProgress(4)
+	assert.EqualValues(t, 40, u.Progress())
+
+	// Empty uploads are finished from the start.
+	u = NewUploadFromBytes([]byte{})
+	assert.EqualValues(t, 100, u.Progress())
+
+	u = NewUploadWithDeferredLength(bytes.NewReader(nil), nil, "")
+	assert.EqualValues(t, 0, u.Progress())
+}
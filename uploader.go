@@ -1,33 +1,96 @@
 package tus
 
 import (
-	"bytes"
+	"context"
+	"sync"
+	"time"
 )
 
+// maxChecksumRetries is the number of times a chunk is sent again after a checksum mismatch.
+const maxChecksumRetries = 3
+
+// prefetchedChunck is a chunck being read ahead.
+type prefetchedChunck struct {
+	offset int64
+	data   []byte
+	err    error
+	done   chan struct{}
+}
+
 type Uploader struct {
 	client     *Client
 	url        string
 	upload     *Upload
 	offset     int64
-	aborted    bool
-	uploadSubs []chan Upload
-	notifyChan chan bool
+	expires    time.Time
+	record     *UploadRecord
+	prefetched *prefetchedChunck
+
+	// Guards state and cancel, and the offset and expires written while uploading.
+	mu     sync.Mutex
+	state  UploadState
+	cancel context.CancelFunc
+
+	events uploadEvents
+	stats  uploadStats
 }
 
 // Subscribes to progress updates.
+// Updates are dropped while the channel isn't ready to receive them, so they never block the upload.
 func (u *Uploader) NotifyUploadProgress(c chan Upload) {
-	u.uploadSubs = append(u.uploadSubs, c)
+	u.events.mu.Lock()
+	defer u.events.mu.Unlock()
+
+	u.events.subscribers = append(u.events.subscribers, c)
 }
 
 // Abort aborts the upload process.
 // It doens't abort the current chunck, only the remaining.
 func (u *Uploader) Abort() {
-	u.aborted = true
+	u.mu.Lock()
+
+	if u.state == StateAborted || u.state == StateCompleted {
+		u.mu.Unlock()
+		return
+	}
+
+	u.state = StateAborted
+	u.mu.Unlock()
+
+	u.events.abort(u.progressEvent())
 }
 
 // IsAborted returns true if the upload was aborted.
 func (u *Uploader) IsAborted() bool {
-	return u.aborted
+	return u.State() == StateAborted
+}
+
+// Terminate terminates the upload on the server and aborts the upload process.
+// On success the upload fingerprint is removed from the Store.
+func (u *Uploader) Terminate() error {
+	return u.TerminateWithContext(context.Background())
+}
+
+// TerminateWithContext terminates the upload on the server using the context for the request,
+// and aborts the upload process. The uploader is left as is if the upload can't be terminated.
+// If the server doesn't know the upload anymore, the upload is aborted and its fingerprint
+// is removed from the Store, but ErrUploadNotFound is still returned.
+func (u *Uploader) TerminateWithContext(ctx context.Context) error {
+	err := u.client.TerminateUploadWithContext(ctx, u.url)
+
+	if err != nil && err != ErrUploadNotFound {
+		return err
+	}
+
+	u.Abort()
+
+	if u.client.Config.Resume && len(u.upload.Fingerprint) > 0 {
+		if err := u.client.deleteRecord(ctx, u.upload.Fingerprint); err != nil {
+			return err
+		}
+	}
+
+	return err
 }
 
 // Url returns the upload url.
@@ -35,15 +98,120 @@ func (u *Uploader) Url() string {
 	return u.url
 }
 
+// Expires returns when the server will expire the upload if it isn't finished.
+// It is the zero time if the server didn't inform an expiration.
+func (u *Uploader) Expires() time.Time {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.expires
+}
+
+// updateExpires updates the upload expiration informed by the server.
+func (u *Uploader) updateExpires(expires time.Time) {
+	if expires.IsZero() {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.expires = expires
+}
+
+// setOffset updates the offset acknowledged by the server.
+func (u *Uploader) setOffset(offset int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.offset = offset
+}
+
+// saveRecord saves the upload state in the store, if the upload is resumable.
+func (u *Uploader) saveRecord(ctx context.Context) error {
+	if u.record == nil {
+		return nil
+	}
+
+	u.record.Size = u.upload.size
+	u.record.Offset = u.offset
+	u.record.ExpiresAt = u.expires
+
+	return u.client.saveRecord(ctx, u.record)
+}
+
+// syncOffset fetches the upload offset from the server, which may be ahead of the offset
+// known by the uploader after an interrupted chunck.
+func (u *Uploader) syncOffset(ctx context.Context) error {
+	offset, expires, err := u.client.getUploadOffset(ctx, u.url)
+
+	if err != nil {
+		return err
+	}
+
+	u.updateExpires(expires)
+	u.setOffset(offset)
+	u.upload.updateProgress(offset)
+	u.stats.setOffset(offset)
+
+	return nil
+}
+
 // Offset returns the current offset uploaded.
 func (u *Uploader) Offset() int64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
 	return u.offset
 }
 
 // Upload uploads the entire body to the server.
 func (u *Uploader) Upload() error {
-	for u.offset < u.upload.size && !u.aborted {
-		err := u.UploadChunck()
+	return u.UploadWithContext(context.Background())
+}
+
+// UploadWithContext uploads the entire body to the server.
+// Cancelling the context interrupts the current chunck and returns the context error.
+// Failed chuncks are retried according to the Config.RetryPolicy.
+// It returns ErrUploadPaused if the upload is paused.
+func (u *Uploader) UploadWithContext(ctx context.Context) error {
+	ctx, err := u.start(ctx)
+
+	if err != nil || ctx == nil {
+		return err
+	}
+
+	u.stats.start(u.offset)
+
+	err = u.stop(u.uploadChuncks(ctx))
+
+	if err != nil && err != ErrUploadPaused {
+		u.events.error(err)
+	}
+
+	return err
+}
+
+// uploadChuncks uploads the remaining chuncks, retrying the failed ones.
+func (u *Uploader) uploadChuncks(ctx context.Context) error {
+	attempts := 0
+
+	for !u.finished() && u.running() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := u.UploadChunckWithContext(ctx)
+
+		if err == nil {
+			attempts = 0
+			continue
+		}
+
+		for err != nil && u.canRetry(err, attempts) {
+			attempts++
+			err = u.retry(ctx, attempts, err)
+		}
 
 		if err != nil {
 			return err
@@ -55,61 +223,131 @@ func (u *Uploader) Upload() error {
 
 // UploadChunck uploads a single chunck.
 func (u *Uploader) UploadChunck() error {
-	data := make([]byte, u.client.Config.ChunkSize)
+	return u.UploadChunckWithContext(context.Background())
+}
+
+// UploadChunckWithContext uploads a single chunck using the context for the request.
+func (u *Uploader) UploadChunckWithContext(ctx context.Context) error {
+	offset := u.offset
 
-	_, err := u.upload.stream.Seek(u.offset, 0)
+	data, err := u.readChunck(u.offset)
 
 	if err != nil {
 		return err
 	}
 
-	size, err := u.upload.stream.Read(data)
+	length := int64(-1)
 
-	if err != nil {
-		return err
+	// A short chunck is the last one, declare the upload size along with it.
+	if u.upload.sizeIsDeferred && int64(len(data)) < u.client.Config.ChunkSize {
+		length = u.offset + int64(len(data))
 	}
 
-	body := bytes.NewBuffer(data[:size])
+	started := time.Now()
+
+	u.stats.chunckSent(len(data))
+	newOffset, expires, err := u.client.uploadChunck(ctx, u.url, data, u.offset, length)
 
-	newOffset, err := u.client.uploadChunck(u.url, body, int64(size), u.offset)
+	// The chunk was corrupted on the way, send it again.
+	for retries := 0; err == ErrChecksumMismatch && retries < maxChecksumRetries; retries++ {
+		u.stats.chunckSent(len(data))
+		newOffset, expires, err = u.client.uploadChunck(ctx, u.url, data, u.offset, length)
+	}
+
+	latency := time.Since(started)
 
 	if err != nil {
 		return err
 	}
 
-	u.offset = newOffset
+	u.updateExpires(expires)
+
+	if length >= 0 {
+		u.upload.declareSize(length)
+	}
+
+	u.setOffset(newOffset)
 
 	u.upload.updateProgress(u.offset)
+	u.stats.chunckAcked(len(data), u.offset, u.upload.Size(), latency)
 
-	u.notifyChan <- true
+	if err := u.saveRecord(ctx); err != nil {
+		return err
+	}
+
+	u.events.chunkComplete(ChunkEvent{
+		Url:      u.url,
+		Offset:   offset,
+		Length:   int64(len(data)),
+		Duration: latency,
+	})
+
+	progress := u.progressEvent()
+	u.events.progress(progress, *u.upload)
+
+	if u.finished() {
+		u.events.complete(progress)
+	}
 
 	return nil
 }
 
-// Waits for a signal to broadcast to all subscribers
-func (u *Uploader) broadcastProgress() {
-	for _ = range u.notifyChan {
-		for _, c := range u.uploadSubs {
-			c <- *u.upload
+// readChunck returns the chunck starting at offset. When the upload body can be read
+// concurrently, the next chunck is read ahead while this one is sent.
+func (u *Uploader) readChunck(offset int64) ([]byte, error) {
+	size := u.client.Config.ChunkSize
+
+	var data []byte
+	var err error
+
+	if p := u.prefetched; p != nil && p.offset == offset {
+		<-p.done
+		data, err = p.data, p.err
+	} else {
+		data, err = u.upload.readChunck(offset, size)
+	}
+
+	u.prefetched = nil
+
+	if err != nil {
+		return nil, err
+	}
+
+	next := offset + int64(len(data))
+
+	if _, ok := u.upload.readerAt(); ok && int64(len(data)) == size && (u.upload.sizeIsDeferred || next < u.upload.size) {
+		p := &prefetchedChunck{
+			offset: next,
+			done:   make(chan struct{}),
 		}
+
+		go func() {
+			defer close(p.done)
+			p.data, p.err = u.upload.readChunck(p.offset, size)
+		}()
+
+		u.prefetched = p
 	}
+
+	return data, nil
+}
+
+// finished returns whether the whole body was uploaded.
+func (u *Uploader) finished() bool {
+	return !u.upload.sizeIsDeferred && u.offset >= u.upload.size
 }
 
 // NewUploader creates a new Uploader.
 func NewUploader(client *Client, url string, upload *Upload, offset int64) *Uploader {
-	notifyChan := make(chan bool)
-
-	uploader := &Uploader{
-		client,
-		url,
-		upload,
-		offset,
-		false,
-		nil,
-		notifyChan,
+	return &Uploader{
+		client: client,
+		url:    url,
+		upload: upload,
+		offset: offset,
+		stats: uploadStats{
+			url:   url,
+			size:  upload.Size(),
+			acked: offset,
+		},
 	}
-
-	go uploader.broadcastProgress()
-
-	return uploader
 }
@@ -0,0 +1,333 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tus/tusd/pkg/filestore"
+)
+
+func TestUploadWithContextCancel(t *testing.T) {
+	started := make(chan struct{})
+	finished := make(chan struct{})
+
+	// Reads the beginning of the chunck and then stalls until the test is finished.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.CopyN(ioutil.Discard, r.Body, 1024)
+		close(started)
+		<-finished
+	}))
+	defer ts.Close()
+	defer close(finished)
+
+	client, err := NewClient(ts.URL, nil)
+	assert.Nil(t, err)
+
+	uploader := NewUploader(client, ts.URL+"/123", NewUploadFromBytes(make([]byte, 1024*1024)), 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	done := make(chan error)
+
+	go func() {
+		done <- uploader.UploadWithContext(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, context.Canceled, err)
+		assert.EqualValues(t, 0, uploader.Offset())
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload wasn't interrupted")
+	}
+}
+
+func TestCreateUploadWithContextDeadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.CreateUploadWithContext(ctx, NewUploadFromBytes([]byte("1234567890")))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func testUploadWithDeferredLength(t *testing.T, size int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	ts := httptest.NewServer(newTusdHandler(store))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChunkSize = 4
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	data := []byte("1234567890")[:size]
+
+	// Hides the Seek method from NewUploadWithDeferredLength.
+	reader := struct{ io.Reader }{bytes.NewReader(data)}
+
+	upload := NewUploadWithDeferredLength(reader, nil, "")
+	assert.True(t, upload.SizeIsDeferred())
+	assert.EqualValues(t, -1, upload.Size())
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+	assert.False(t, upload.SizeIsDeferred())
+	assert.EqualValues(t, size, upload.Size())
+	assert.EqualValues(t, size, uploader.Offset())
+
+	up, err := store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	assert.Nil(t, err)
+
+	fi, err := up.GetInfo(ctx)
+	assert.Nil(t, err)
+	assert.False(t, fi.SizeIsDeferred)
+	assert.EqualValues(t, size, fi.Size)
+	assert.EqualValues(t, size, fi.Offset)
+
+	content, err := up.GetReader(ctx)
+	assert.Nil(t, err)
+
+	b, err := ioutil.ReadAll(content)
+	assert.Nil(t, err)
+	assert.Equal(t, data, b)
+}
+
+func TestUploadWithDeferredLength(t *testing.T) {
+	testUploadWithDeferredLength(t, 10)
+}
+
+func TestUploadWithDeferredLengthMultipleOfChunkSize(t *testing.T) {
+	testUploadWithDeferredLength(t, 8)
+}
+
+func TestUploadWithDeferredLengthEmpty(t *testing.T) {
+	testUploadWithDeferredLength(t, 0)
+}
+
+func TestUploadWithDeferredLengthNotSupported(t *testing.T) {
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	ts := httptest.NewServer(extensionsHandler(newTusdHandler(store), "creation", nil))
+	defer ts.Close()
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), nil)
+	assert.Nil(t, err)
+
+	_, err = client.CreateUpload(NewUploadWithDeferredLength(bytes.NewReader([]byte("1234")), nil, ""))
+	assert.Equal(t, ErrDeferLengthNotSupported, err)
+}
+
+func TestUploadStreamRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+
+	client, store, closeServer := newTestClient(t, flaky(map[int32]int{2: 0, 3: 503}), withRetryPolicy(policy))
+	defer closeServer()
+
+	data := make([]byte, 1024)
+
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	// Hides the Seek method from NewUpload.
+	reader := struct{ io.Reader }{bytes.NewReader(data)}
+
+	upload := NewUpload(reader, int64(len(data)), nil, "")
+	assert.False(t, upload.seekable())
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+
+	up, err := store.GetUpload(ctx, uploadIDFromURL(uploader.Url()))
+	assert.Nil(t, err)
+
+	content, err := up.GetReader(ctx)
+	assert.Nil(t, err)
+
+	b, err := ioutil.ReadAll(content)
+	assert.Nil(t, err)
+	assert.Equal(t, data, b)
+}
+
+// readerAtRecorder records the offsets read and fails on Seek.
+type readerAtRecorder struct {
+	*bytes.Reader
+
+	mu    sync.Mutex
+	reads map[int64]chan struct{}
+	seeks int
+}
+
+func newReaderAtRecorder(data []byte) *readerAtRecorder {
+	return &readerAtRecorder{
+		Reader: bytes.NewReader(data),
+		reads:  make(map[int64]chan struct{}),
+	}
+}
+
+// read returns a channel closed once the offset was read.
+func (r *readerAtRecorder) read(off int64) chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.reads[off]; !ok {
+		r.reads[off] = make(chan struct{})
+	}
+
+	return r.reads[off]
+}
+
+func (r *readerAtRecorder) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.Reader.ReadAt(p, off)
+	close(r.read(off))
+	return n, err
+}
+
+func (r *readerAtRecorder) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	r.seeks++
+	r.mu.Unlock()
+
+	return r.Reader.Seek(offset, whence)
+}
+
+func TestUploadPrefetchesNextChunck(t *testing.T) {
+	store := filestore.FileStore{
+		Path: os.TempDir(),
+	}
+
+	data := make([]byte, 16)
+	reader := newReaderAtRecorder(data)
+
+	var prefetched int32
+
+	tusd := newTusdHandler(store)
+
+	// Holds each PATCH until the next chunck is read, which happens only if it's read ahead.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			offset, _ := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+
+			if offset+4 < int64(len(data)) {
+				select {
+				case <-reader.read(offset + 4):
+					atomic.AddInt32(&prefetched, 1)
+				case <-time.After(2 * time.Second):
+				}
+			}
+		}
+
+		tusd.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	cfg := DefaultConfig()
+	cfg.ChunkSize = 4
+
+	client, err := NewClient(fmt.Sprintf("%s/uploads/", ts.URL), cfg)
+	assert.Nil(t, err)
+
+	uploader, err := client.CreateUpload(NewUpload(reader, int64(len(data)), nil, ""))
+	assert.Nil(t, err)
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+	assert.EqualValues(t, 16, uploader.Offset())
+
+	// The creation request sends the first chunck, the next two PATCH wait for the last ones.
+	assert.EqualValues(t, 2, atomic.LoadInt32(&prefetched))
+	assert.Equal(t, 0, reader.seeks)
+}
+
+func TestTerminateUploadNotSupported(t *testing.T) {
+	client, _, closeServer := newTestClient(t, func(h http.Handler) http.Handler {
+		return extensionsHandler(h, "creation", nil)
+	}, withStore)
+	defer closeServer()
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+	upload.Fingerprint = "not-terminated"
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+
+	err = uploader.Terminate()
+	assert.Equal(t, ErrTerminationNotSupported, err)
+
+	// Nothing was terminated, the upload can still be uploaded and resumed.
+	assert.False(t, uploader.IsAborted())
+
+	_, found := client.Config.Store.Get(client.storeKeyPrefix() + upload.Fingerprint)
+	assert.True(t, found)
+
+	err = uploader.Upload()
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, uploader.Offset())
+}
+
+func TestTerminateUploadNotFound(t *testing.T) {
+	client, _, closeServer := newTestClient(t, nil, withStore)
+	defer closeServer()
+
+	upload := NewUploadFromBytes([]byte("1234567890"))
+	upload.Fingerprint = "already-terminated"
+
+	uploader, err := client.CreateUpload(upload)
+	assert.Nil(t, err)
+
+	err = client.TerminateUpload(uploader.Url())
+	assert.Nil(t, err)
+
+	// The server doesn't know the upload anymore, so its record is removed.
+	err = uploader.Terminate()
+	assert.Equal(t, ErrUploadNotFound, err)
+	assert.True(t, uploader.IsAborted())
+
+	_, found := client.Config.Store.Get(client.storeKeyPrefix() + upload.Fingerprint)
+	assert.False(t, found)
+}